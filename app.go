@@ -3,12 +3,24 @@ package main
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"sync"
+	"time"
 
+	"wails-launcher/pkg/compose"
 	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/devcert"
 	"wails-launcher/pkg/group"
+	"wails-launcher/pkg/portalloc"
 	"wails-launcher/pkg/process"
+	"wails-launcher/pkg/project"
+	"wails-launcher/pkg/proxy"
+	"wails-launcher/pkg/redact"
+	"wails-launcher/pkg/secrets"
 	"wails-launcher/pkg/service"
+	"wails-launcher/pkg/solution"
+	"wails-launcher/pkg/traffic"
+	"wails-launcher/pkg/watch"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -36,11 +48,30 @@ type ServiceInfo = service.ServiceInfo
 
 // App struct
 type App struct {
-	ctx      context.Context
-	services map[string]*service.Service
-	groups   *group.Manager
-	config   *config.Config
-	mu       sync.RWMutex
+	ctx       context.Context
+	services  map[string]*service.Service
+	groups    *group.Manager
+	config    *config.Config
+	ports     *portalloc.Allocator
+	proxy     *proxy.Server
+	proxyRun  sync.Mutex // Serializes proxy restarts
+	proxyMu   sync.Mutex // Guards proxyErr and proxyGen
+	proxyErr  string
+	proxyGen  int        // Bumped by every refresh, older pending restarts are skipped
+	caMu      sync.Mutex // Guards ca, which is created on first use
+	ca        *devcert.Authority
+	traffic   *traffic.Store
+	watcher   *watch.Watcher
+	secrets   *secrets.Store
+	redaction *redact.Policy
+	mu        sync.RWMutex
+
+	configErr   error                             // Why the config file could not be loaded, saving is disabled while set
+	saveErr     error                             // Why the last save of the config file failed
+	watchErr    error                             // Why the config files are not watched
+	problems    map[string]config.ValidationError // Validation problems by service ID, invalid services cannot start
+	projectErrs map[string]string                 // Load or save errors of project files by root
+	secretsErr  error                             // Why secrets are not kept in the keyring
 }
 
 // EmitToFrontend emits an event to the frontend
@@ -56,30 +87,50 @@ func (a *App) EmitToFrontend(event string, serviceId string, data interface{}) {
 func NewApp() *App {
 	cfg, err := config.Load()
 	if err != nil {
-		// Handle error, maybe create empty config
+		// Start empty but never save over a file that could not be read,
+		// e.g. one written by a newer launcher
 		cfg = &config.Config{Groups: make(map[string]config.GroupConfig)}
 	}
 
 	app := &App{
-		services: make(map[string]*service.Service),
-		groups:   group.NewManager(cfg.Groups),
-		config:   cfg,
+		services:  make(map[string]*service.Service),
+		config:    cfg,
+		configErr: err,
+		ports:     portalloc.NewAllocator(portRange(cfg)),
+		traffic:   traffic.NewStore(),
+		redaction: redact.NewPolicy(redactionRules(cfg)),
 	}
+	app.secrets, app.secretsErr = openSecrets()
+	app.groups = group.NewManager(app.withProjects(cfg))
+	app.groups.SetEnvironment(cfg.Environment)
+	app.validate()
+	app.proxy = proxy.NewServer(app.proxyTarget)
+	app.proxy.SetRecorder(app.traffic)
 	app.loadServices()
 	return app
 }
 
+// portRange returns the configured named port range or the default one
+func portRange(cfg *config.Config) (int, int) {
+	if cfg.PortRange == nil {
+		return portalloc.DefaultRangeStart, portalloc.DefaultRangeEnd
+	}
+	return cfg.PortRange.Start, cfg.PortRange.End
+}
+
 // startup is called when the app starts. The context is saved
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.refreshProxy()
+	a.watchConfig()
 }
 
 // loadServices loads services from configuration
 func (a *App) loadServices() {
 	groupServices := a.groups.GetGroupServices()
 	for serviceId, enriched := range groupServices {
-		srv := service.NewService(serviceId, enriched.Config, enriched.InheritedEnv, a)
+		srv := service.NewService(serviceId, enriched.GroupID, enriched.Config, enriched.InheritedEnv, a, a.ports, a.secrets, a.redaction)
 		a.services[serviceId] = srv
 	}
 }
@@ -90,13 +141,15 @@ func (a *App) GetServices() map[string]ServiceInfo {
 	defer a.mu.RUnlock()
 	result := make(map[string]ServiceInfo)
 	for id, srv := range a.services {
-		result[id] = srv.GetInfo()
+		info := a.withProxyURL(id, srv.GetInfo())
+		info.Problems = a.problems[id]
+		result[id] = info
 	}
 	return result
 }
 
 // AddService adds a new service to the default group
-func (a *App) AddService(config ServiceConfig) *service.Service {
+func (a *App) AddService(config ServiceConfig) (*service.Service, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -110,11 +163,14 @@ func (a *App) AddService(config ServiceConfig) *service.Service {
 		}
 	}
 	if defaultGroupId == "" {
-		defaultGroupId = a.AddGroup("Default", make(ServiceEnv))
+		defaultGroupId, _ = a.addGroup("Default", make(ServiceEnv), nil)
 	}
 
-	serviceId := a.AddServiceToGroup(defaultGroupId, config)
-	return a.services[serviceId]
+	serviceId, err := a.addServiceToGroup(defaultGroupId, config)
+	if err != nil {
+		return nil, err
+	}
+	return a.services[serviceId], nil
 }
 
 // GetService returns a service by ID
@@ -125,25 +181,31 @@ func (a *App) GetService(id string) *ServiceInfo {
 	if !exists {
 		return nil
 	}
-	info := srv.GetInfo()
+	info := a.withProxyURL(id, srv.GetInfo())
+	info.Problems = a.problems[id]
 	return &info
 }
 
 // UpdateService updates a service (assumes it's in default group for backward compatibility)
-func (a *App) UpdateService(id string, config ServiceConfig) *service.Service {
+func (a *App) UpdateService(id string, config ServiceConfig) (*service.Service, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	// Find the group containing this service
 	if groupId, found := a.groups.FindGroupByService(id); found {
-		a.UpdateServiceInGroup(groupId, id, config)
-		return a.services[id]
+		if err := a.updateServiceInGroup(groupId, id, config); err != nil {
+			return nil, err
+		}
+		return a.services[id], nil
 	}
-	return nil
+	return nil, fmt.Errorf("service not found")
 }
 
 // StartService starts a service
 func (a *App) StartService(id string) error {
+	if err := a.checkStartable(id); err != nil {
+		return err
+	}
 	a.mu.RLock()
 	srv, exists := a.services[id]
 	a.mu.RUnlock()
@@ -155,6 +217,9 @@ func (a *App) StartService(id string) error {
 
 // StartServiceWithoutBuild starts a service without building
 func (a *App) StartServiceWithoutBuild(id string) error {
+	if err := a.checkStartable(id); err != nil {
+		return err
+	}
 	a.mu.RLock()
 	srv, exists := a.services[id]
 	a.mu.RUnlock()
@@ -164,6 +229,21 @@ func (a *App) StartServiceWithoutBuild(id string) error {
 	return srv.StartWithoutBuild()
 }
 
+// ResolvePortConflict applies the chosen resolution ("kill", "reassign" or "abort")
+// for a port conflict reported when starting a service
+func (a *App) ResolvePortConflict(id string, action string, withoutBuild bool) error {
+	if err := a.checkStartable(id); err != nil && action != "abort" {
+		return err
+	}
+	a.mu.RLock()
+	srv, exists := a.services[id]
+	a.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("service not found")
+	}
+	return srv.ResolvePortConflict(action, withoutBuild)
+}
+
 // StopService stops a service
 func (a *App) StopService(id string) error {
 	a.mu.RLock()
@@ -186,37 +266,10 @@ func (a *App) ClearLogs(id string) {
 	srv.ClearLogs()
 }
 
-// ReloadServices reloads services from config
+// ReloadServices reloads services from config. Running services are only
+// restarted when their path, type or effective environment changed.
 func (a *App) ReloadServices() {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	// Reload config
-	cfg, err := config.Load()
-	if err != nil {
-		return
-	}
-	a.config = cfg
-	a.groups = group.NewManager(cfg.Groups)
-
-	// Stop services not in config
-	groupServices := a.groups.GetGroupServices()
-	for id, srv := range a.services {
-		if _, exists := groupServices[id]; !exists {
-			srv.Stop()
-			delete(a.services, id)
-		}
-	}
-
-	// Update or create services
-	for id, enriched := range groupServices {
-		if srv, exists := a.services[id]; exists {
-			srv.UpdateConfig(enriched.Config, enriched.InheritedEnv)
-		} else {
-			srv := service.NewService(id, enriched.Config, enriched.InheritedEnv, a)
-			a.services[id] = srv
-		}
-	}
+	a.reloadConfig()
 }
 
 // GetGroups returns all groups
@@ -225,15 +278,31 @@ func (a *App) GetGroups() map[string]config.GroupConfig {
 }
 
 // AddGroup adds a new group
-func (a *App) AddGroup(name string, env config.ServiceEnv) string {
-	groupId := a.groups.AddGroup(name, env)
+func (a *App) AddGroup(name string, env config.ServiceEnv, envFiles []string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.addGroup(name, env, envFiles)
+}
+
+// addGroup adds a group, the caller holds a.mu
+func (a *App) addGroup(name string, env config.ServiceEnv, envFiles []string) (string, error) {
+	if err := validateGroupChange(config.GroupConfig{Name: name, Env: env, EnvFiles: envFiles}); err != nil {
+		return "", err
+	}
+	groupId := a.groups.AddGroup(name, env, envFiles)
 	a.saveConfig()
-	return groupId
+	return groupId, nil
 }
 
 // UpdateGroup updates a group
-func (a *App) UpdateGroup(id string, name string, env config.ServiceEnv) {
-	a.groups.UpdateGroup(id, name, env)
+func (a *App) UpdateGroup(id string, name string, env config.ServiceEnv, envFiles []string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	project := a.groups.GetGroups()[id].Project
+	if err := validateGroupChange(config.GroupConfig{Name: name, Env: env, EnvFiles: envFiles, Project: project}); err != nil {
+		return err
+	}
+	a.groups.UpdateGroup(id, name, env, envFiles)
 	a.saveConfig()
 
 	// Update all services in the group with new merged env
@@ -248,24 +317,45 @@ func (a *App) UpdateGroup(id string, name string, env config.ServiceEnv) {
 			}
 		}
 	}
+	return nil
 }
 
 // AddServiceToGroup adds a service to a group
-func (a *App) AddServiceToGroup(groupId string, config config.ServiceConfig) string {
+func (a *App) AddServiceToGroup(groupId string, config config.ServiceConfig) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.addServiceToGroup(groupId, config)
+}
+
+// addServiceToGroup adds a service to a group, the caller holds a.mu
+func (a *App) addServiceToGroup(groupId string, config config.ServiceConfig) (string, error) {
+	if errs := a.validateCandidate(groupId, "", config); len(errs) > 0 {
+		return "", errs
+	}
 	serviceId := a.groups.AddServiceToGroup(groupId, config)
 	a.saveConfig()
 
 	// Create the service
 	groupServices := a.groups.GetGroupServices()
 	if enriched, exists := groupServices[serviceId]; exists {
-		srv := service.NewService(serviceId, enriched.Config, enriched.InheritedEnv, a)
+		srv := service.NewService(serviceId, enriched.GroupID, enriched.Config, enriched.InheritedEnv, a, a.ports, a.secrets, a.redaction)
 		a.services[serviceId] = srv
 	}
-	return serviceId
+	return serviceId, nil
 }
 
 // UpdateServiceInGroup updates a service in a group
-func (a *App) UpdateServiceInGroup(groupId string, serviceId string, config config.ServiceConfig) {
+func (a *App) UpdateServiceInGroup(groupId string, serviceId string, config config.ServiceConfig) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.updateServiceInGroup(groupId, serviceId, config)
+}
+
+// updateServiceInGroup updates a service in a group, the caller holds a.mu
+func (a *App) updateServiceInGroup(groupId string, serviceId string, config config.ServiceConfig) error {
+	if errs := a.validateCandidate(groupId, serviceId, config); len(errs) > 0 {
+		return errs
+	}
 	a.groups.UpdateServiceInGroup(groupId, serviceId, config)
 	a.saveConfig()
 
@@ -276,41 +366,162 @@ func (a *App) UpdateServiceInGroup(groupId string, serviceId string, config conf
 			srv.UpdateConfig(enriched.Config, enriched.InheritedEnv)
 		}
 	}
+	return nil
 }
 
-// ImportSLN imports projects from a .sln file and creates a group
-func (a *App) ImportSLN(slnPath string) error {
-	err := a.groups.ImportSLN(slnPath)
+// SolutionProject is a project of a solution with what the import makes of it
+type SolutionProject struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Folder   string `json:"folder,omitempty"`
+	Kind     string `json:"kind"`
+	Runnable bool   `json:"runnable"` // Selected for import by default
+	Reason   string `json:"reason"`
+}
+
+// PreviewSolution lists the projects of a .sln, .slnx or .slnf file with
+// whether ImportSLN would import them and why
+func (a *App) PreviewSolution(slnPath string) ([]SolutionProject, error) {
+	sln, err := solution.Load(slnPath)
+	if err != nil {
+		return nil, err
+	}
+	projects := make([]SolutionProject, 0, len(sln.Projects))
+	for _, project := range sln.Projects {
+		projects = append(projects, solutionProject(project))
+	}
+	return projects, nil
+}
+
+// solutionProject detects what kind of project a solution project is
+func solutionProject(project solution.Project) SolutionProject {
+	detection := solution.Detect(project.Path)
+	return SolutionProject{
+		Name:     project.Name,
+		Path:     project.Path,
+		Folder:   project.Folder,
+		Kind:     detection.Kind,
+		Runnable: detection.Runnable,
+		Reason:   detection.Reason,
+	}
+}
+
+// SolutionSync is what syncing a group with its solution would change
+type SolutionSync struct {
+	Solution  string            `json:"solution"`
+	Added     []SolutionProject `json:"added"`     // Projects without a service
+	Removed   []string          `json:"removed"`   // IDs of services whose project left the solution
+	Unchanged []string          `json:"unchanged"` // IDs of services kept as they are
+}
+
+// PreviewSync compares a group with the solution it was imported from
+func (a *App) PreviewSync(groupId string) (SolutionSync, error) {
+	a.mu.RLock()
+	diff, err := a.groups.DiffSolution(groupId)
+	a.mu.RUnlock()
+	if err != nil {
+		return SolutionSync{}, err
+	}
+	sync := SolutionSync{
+		Solution:  diff.Solution,
+		Added:     make([]SolutionProject, 0, len(diff.Added)),
+		Removed:   append([]string{}, diff.Removed...), // Never null for the frontend
+		Unchanged: append([]string{}, diff.Unchanged...),
+	}
+	for _, project := range diff.Added {
+		sync.Added = append(sync.Added, solutionProject(project))
+	}
+	return sync, nil
+}
+
+// SyncGroup adds services for the given new projects of a group's solution
+// and removes the given services whose project left it. Other services keep
+// their IDs and settings.
+func (a *App) SyncGroup(groupId string, addPaths []string, removeIds []string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	added, err := a.groups.SyncSolution(groupId, addPaths, removeIds)
 	if err != nil {
 		return err
 	}
+	for _, serviceId := range removeIds {
+		a.dropService(serviceId)
+	}
+	groupServices := a.groups.GetGroupServices()
+	for _, serviceId := range added {
+		enriched := groupServices[serviceId]
+		a.services[serviceId] = service.NewService(serviceId, enriched.GroupID, enriched.Config, enriched.InheritedEnv, a, a.ports, a.secrets, a.redaction)
+	}
 	a.saveConfig()
+	return nil
+}
 
-	// Create services for the new group
-	groupServices := a.groups.GetGroupServices()
-	for serviceId, enriched := range groupServices {
-		if _, exists := a.services[serviceId]; !exists {
-			srv := service.NewService(serviceId, enriched.Config, enriched.InheritedEnv, a)
-			a.services[serviceId] = srv
-		}
+// ImportSLN imports projects from a solution file and creates a group. The
+// project file paths select the projects, none imports the runnable ones.
+// The problems of imported services that cannot start are returned.
+func (a *App) ImportSLN(slnPath string, projectPaths []string) ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	groupId, err := a.groups.ImportSLN(slnPath, projectPaths)
+	if err != nil {
+		return nil, err
 	}
+	return a.addImportedGroup(groupId), nil
+}
 
-	return nil
+// ImportCompose creates a group from the services of a compose file and
+// returns the problems of those that cannot start
+func (a *App) ImportCompose(path string) ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	groupId, err := a.groups.ImportCompose(path)
+	if err != nil {
+		return nil, err
+	}
+	return a.addImportedGroup(groupId), nil
 }
 
-// ImportProject imports a single project into a group
-func (a *App) ImportProject(groupId string, path string, projectType string) error {
-	serviceId, err := a.groups.ImportProject(groupId, path, projectType)
+// GetComposeServices returns the service names of the compose file of a
+// directory, file picks one relative to it
+func (a *App) GetComposeServices(path string, file string) ([]string, error) {
+	svc := config.ServiceConfig{Path: path, ComposeFile: file}
+	composePath, err := svc.ComposeFilePath()
+	if err != nil {
+		return nil, err
+	}
+	project, err := compose.Load(composePath)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, s := range project.Services {
+		names = append(names, s.Name)
+	}
+	return names, nil
+}
+
+// ImportProject imports a single project into a group. script picks the
+// package.json script of an npm project, empty for its dev script.
+func (a *App) ImportProject(groupId string, path string, projectType string, script string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	serviceId, err := a.groups.ImportProject(groupId, path, projectType, script)
 	if err != nil {
 		return err
 	}
+	a.validate()
+	if errs, invalid := a.problems[serviceId]; invalid {
+		a.groups.DeleteServiceFromGroup(groupId, serviceId)
+		a.validate()
+		return errs
+	}
 	a.saveConfig()
 
 	// Create the service
 	groupServices := a.groups.GetGroupServices()
 	if enriched, exists := groupServices[serviceId]; exists {
 		if _, exists := a.services[serviceId]; !exists {
-			srv := service.NewService(serviceId, enriched.Config, enriched.InheritedEnv, a)
+			srv := service.NewService(serviceId, enriched.GroupID, enriched.Config, enriched.InheritedEnv, a, a.ports, a.secrets, a.redaction)
 			a.services[serviceId] = srv
 		}
 	}
@@ -339,36 +550,81 @@ func (a *App) DeleteService(serviceId string) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	// Stop the service if running
+	groupId, found := a.groups.FindGroupByService(serviceId)
+	if !found {
+		return fmt.Errorf("service not found")
+	}
+	// Services shared through a project file can only be removed from that file
+	if root := a.groups.GetGroups()[groupId].Project; root != "" && project.Defines(root, serviceId) {
+		return fmt.Errorf("service is defined in %s, remove it there", filepath.Join(root, project.FileName))
+	}
+
+	a.dropService(serviceId)
+	a.groups.DeleteServiceFromGroup(groupId, serviceId)
+	a.saveConfig()
+	return nil
+}
+
+// dropService stops a service being removed from the config and releases
+// what it holds. The caller holds a.mu.
+func (a *App) dropService(serviceId string) {
 	if srv, exists := a.services[serviceId]; exists {
 		srv.Stop()
 		delete(a.services, serviceId)
+		a.ports.Release(serviceId)
+		a.traffic.Clear(serviceId)
 	}
-
-	// Find the group and remove from it
-	if groupId, found := a.groups.FindGroupByService(serviceId); found {
-		a.groups.DeleteServiceFromGroup(groupId, serviceId)
-		a.saveConfig()
-		return nil
-	}
-
-	return fmt.Errorf("service not found")
 }
 
-// StartGroup starts all services in a group
+// StartGroup starts all services in a group, each one after the services it depends on are running
 func (a *App) StartGroup(groupId string) {
 	groups := a.groups.GetGroups()
 	if group, exists := groups[groupId]; exists {
-		for serviceId := range group.Services {
-			go func(id string) {
+		ids := make(map[string]string) // Service name to ID
+		for serviceId, serviceConfig := range group.Services {
+			ids[serviceConfig.Name] = serviceId
+		}
+		for serviceId, serviceConfig := range group.Services {
+			go func(id string, dependsOn []string) {
+				for _, name := range dependsOn {
+					if depId, exists := ids[name]; exists {
+						a.waitForRunning(depId, dependencyTimeout)
+					}
+				}
 				a.StartService(id)
-			}(serviceId)
+			}(serviceId, serviceConfig.DependsOn)
+		}
+	}
+}
+
+// dependencyTimeout bounds how long StartGroup waits for a dependency, so cycles cannot block forever
+const dependencyTimeout = 2 * time.Minute
+
+// waitForRunning waits until a service is running or has failed
+func (a *App) waitForRunning(serviceId string, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		a.mu.RLock()
+		srv, exists := a.services[serviceId]
+		_, invalid := a.problems[serviceId]
+		a.mu.RUnlock()
+		if !exists || invalid {
+			return
 		}
+		if _, status := srv.Endpoint(); status == process.Running || status == process.Error {
+			return
+		}
+		time.Sleep(500 * time.Millisecond)
 	}
 }
 
-// saveConfig saves the configuration
+// saveConfig saves the configuration and applies routing changes to the proxy
 func (a *App) saveConfig() {
-	a.config.Groups = a.groups.GetGroups()
-	a.config.Save()
+	a.validate()
+	groups := a.groups.GetGroups()
+	a.config.Groups = localGroups(groups)
+	a.writeConfig()
+	a.saveProjects(groups)
+	a.updateWatchedFiles()
+	a.refreshProxy()
 }
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/group"
+	"wails-launcher/pkg/project"
+	"wails-launcher/pkg/service"
+	"wails-launcher/pkg/watch"
+)
+
+// ConfigStatus describes the config file and the outcome of the last load and save
+type ConfigStatus struct {
+	Path       string `json:"path"`
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	LoadError  string `json:"loadError,omitempty"` // Saving is disabled until the file loads again
+	SaveError  string `json:"saveError,omitempty"`
+	WatchError string `json:"watchError,omitempty"` // External edits are not picked up
+}
+
+// ConfigReload reports what reloading the config changed, by service name
+type ConfigReload struct {
+	Added     []string `json:"added,omitempty"`
+	Removed   []string `json:"removed,omitempty"`
+	Restarted []string `json:"restarted,omitempty"`
+	Updated   []string `json:"updated,omitempty"` // Changed without needing a restart
+	Error     string   `json:"error,omitempty"`   // Why the files could not be loaded, nothing was applied
+}
+
+// empty reports whether the reload changed nothing
+func (r ConfigReload) empty() bool {
+	return len(r.Added)+len(r.Removed)+len(r.Restarted)+len(r.Updated) == 0 && r.Error == ""
+}
+
+// GetConfigStatus returns where the config is stored and whether it could be loaded and saved
+func (a *App) GetConfigStatus() ConfigStatus {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.configStatus()
+}
+
+// configStatus builds the config status, the caller holds a.mu
+func (a *App) configStatus() ConfigStatus {
+	status := ConfigStatus{Version: a.config.Version}
+	if path, err := config.Path(); err == nil {
+		status.Path = path
+		status.Format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	if a.configErr != nil {
+		status.LoadError = a.configErr.Error()
+	}
+	if a.saveErr != nil {
+		status.SaveError = a.saveErr.Error()
+	}
+	if a.watchErr != nil {
+		status.WatchError = a.watchErr.Error()
+	}
+	return status
+}
+
+// writeConfig saves the config file unless it failed to load and reports
+// the outcome to the frontend, the caller holds a.mu
+func (a *App) writeConfig() {
+	if a.configErr != nil {
+		return
+	}
+	err := a.config.Save()
+	if path, pathErr := config.Path(); err == nil && pathErr == nil {
+		a.written(path)
+	}
+	changed := (err == nil) != (a.saveErr == nil) || err != nil && err.Error() != a.saveErr.Error()
+	a.saveErr = err
+	if changed {
+		a.emitConfigStatus()
+	}
+}
+
+// emitConfigStatus sends the config status to the frontend, the caller holds a.mu
+func (a *App) emitConfigStatus() {
+	if a.ctx != nil {
+		a.EmitToFrontend("configStatus", "", a.configStatus())
+	}
+}
+
+// ListConfigBackups returns the previous versions of the config file, newest first
+func (a *App) ListConfigBackups() ([]config.Backup, error) {
+	return config.ListBackups()
+}
+
+// RestoreConfigBackup replaces the config file with a previous version and reloads it.
+// The current file is backed up first.
+func (a *App) RestoreConfigBackup(name string) error {
+	if err := config.RestoreBackup(name); err != nil {
+		return err
+	}
+	a.ReloadServices()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.configErr
+}
+
+// watchConfig starts watching the config, project and env files for external edits
+func (a *App) watchConfig() {
+	watcher, err := watch.New(a.reloadConfig)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		a.watchErr = fmt.Errorf("failed to watch config files: %w", err)
+		a.emitConfigStatus()
+		return
+	}
+	a.watcher = watcher
+	a.updateWatchedFiles()
+}
+
+// written tells the watcher the app saved path itself, so saving from the UI
+// does not reload the config. The caller holds a.mu.
+func (a *App) written(path string) {
+	if a.watcher != nil {
+		a.watcher.Written(path)
+	}
+}
+
+// updateWatchedFiles points the watcher at the current config and project files, the caller holds a.mu
+func (a *App) updateWatchedFiles() {
+	if a.watcher == nil {
+		return
+	}
+	var files []string
+	if path, err := config.Path(); err == nil {
+		files = append(files, path)
+	}
+	for _, root := range a.projectRoots(a.config) {
+		files = append(files, filepath.Join(root, project.FileName), filepath.Join(root, project.LocalFileName))
+	}
+	for _, grp := range a.groups.GetGroups() {
+		files = append(files, grp.EnvFilePaths()...)
+		for _, svc := range grp.Services {
+			files = append(files, svc.EnvFilePaths()...)
+		}
+	}
+	err := a.watcher.SetFiles(files)
+	if err != nil {
+		err = fmt.Errorf("failed to watch config files: %w", err)
+	}
+	changed := (err == nil) != (a.watchErr == nil) || err != nil && err.Error() != a.watchErr.Error()
+	a.watchErr = err
+	if changed {
+		a.emitConfigStatus()
+	}
+}
+
+// reloadConfig loads the config and project files and applies the difference
+// to the services. A file that fails to load leaves everything as it is.
+func (a *App) reloadConfig() {
+	a.mu.Lock()
+	cfg, err := config.Load()
+	if err != nil {
+		// Keep the services running but don't save over the broken file
+		a.configErr = err
+		a.emitConfigStatus()
+		a.mu.Unlock()
+		a.emitReload(ConfigReload{Error: err.Error()})
+		return
+	}
+	hadErr := a.configErr != nil || a.saveErr != nil
+	a.config = cfg
+	a.configErr = nil
+	a.saveErr = nil
+	if hadErr {
+		a.emitConfigStatus()
+	}
+
+	previous := a.groups.GetGroupServices()
+	a.groups = group.NewManager(a.withProjects(cfg))
+	a.groups.SetEnvironment(cfg.Environment)
+	a.validate()
+	a.ports.SetRange(portRange(cfg))
+	a.redaction.SetRules(redactionRules(cfg))
+	report, restarts := a.applyServices(previous)
+	a.refreshRedaction()
+	a.updateWatchedFiles()
+	a.refreshProxy()
+	a.mu.Unlock()
+
+	for _, restart := range restarts {
+		go restart()
+	}
+	if !report.empty() {
+		a.emitReload(report)
+	}
+}
+
+// applyServices brings the services in line with the loaded groups. Services
+// that need restarting are returned as functions run after a.mu is released.
+// The caller holds a.mu.
+func (a *App) applyServices(previous map[string]group.EnrichedServiceConfig) (ConfigReload, []func()) {
+	var report ConfigReload
+	var restarts []func()
+	groupServices := a.groups.GetGroupServices()
+
+	// Stop services not in config
+	for id, srv := range a.services {
+		if _, exists := groupServices[id]; !exists {
+			report.Removed = append(report.Removed, previous[id].Config.Name)
+			delete(a.services, id)
+			a.ports.Release(id)
+			restarts = append(restarts, func() { srv.Stop() })
+		}
+	}
+
+	// Update or create services
+	for id, enriched := range groupServices {
+		srv, exists := a.services[id]
+		if !exists {
+			a.services[id] = service.NewService(id, enriched.GroupID, enriched.Config, enriched.InheritedEnv, a, a.ports, a.secrets, a.redaction)
+			report.Added = append(report.Added, enriched.Config.Name)
+			continue
+		}
+		// Always update: previous is read after the edit, so it already holds
+		// the new content of group env files, and the service's own env files
+		// are not part of its config. UpdateConfig compares the merged env.
+		same := sameService(previous[id], enriched)
+		active := srv.IsActive()
+		if previous[id].Config.Type != enriched.Config.Type {
+			// The process manager depends on the type, replace the service
+			replacement := service.NewService(id, enriched.GroupID, enriched.Config, enriched.InheritedEnv, a, a.ports, a.secrets, a.redaction)
+			a.services[id] = replacement
+			restarts = append(restarts, func() {
+				srv.Stop()
+				if active {
+					replacement.Start()
+				}
+			})
+		} else if changed := srv.UpdateConfig(enriched.Config, enriched.InheritedEnv); changed && active {
+			restarts = append(restarts, func() { srv.Restart() })
+		} else {
+			if changed || !same {
+				report.Updated = append(report.Updated, enriched.Config.Name)
+			}
+			continue
+		}
+		if active {
+			report.Restarted = append(report.Restarted, enriched.Config.Name)
+		} else {
+			report.Updated = append(report.Updated, enriched.Config.Name)
+		}
+	}
+	return report, restarts
+}
+
+// emitReload reports the outcome of a reload to the frontend
+func (a *App) emitReload(report ConfigReload) {
+	if a.ctx != nil {
+		a.EmitToFrontend("configReloaded", "", report)
+	}
+}
+
+// sameService reports whether two service configs are equal, treating empty and missing values alike
+func sameService(a, b group.EnrichedServiceConfig) bool {
+	return reflect.DeepEqual(normalizedService(a), normalizedService(b))
+}
+
+func normalizedService(s group.EnrichedServiceConfig) group.EnrichedServiceConfig {
+	if len(s.InheritedEnv) == 0 {
+		s.InheritedEnv = nil
+	}
+	if len(s.Config.Env) == 0 {
+		s.Config.Env = nil
+	}
+	if len(s.Config.Ports) == 0 {
+		s.Config.Ports = nil
+	}
+	if len(s.Config.NamedPorts) == 0 {
+		s.Config.NamedPorts = nil
+	}
+	if len(s.Config.DependsOn) == 0 {
+		s.Config.DependsOn = nil
+	}
+	if len(s.Config.EnvFiles) == 0 {
+		s.Config.EnvFiles = nil
+	}
+	return s
+}
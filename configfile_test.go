@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/group"
+	"wails-launcher/pkg/portalloc"
+	"wails-launcher/pkg/redact"
+	"wails-launcher/pkg/service"
+)
+
+func TestApplyServicesGroupEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envFile, []byte("GREETING=hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	groups := map[string]config.GroupConfig{
+		"group": {
+			Name:     "Group",
+			Project:  dir,
+			EnvFiles: []string{".env"},
+			Services: map[string]config.ServiceConfig{
+				"api": {Name: "api", Type: "command", Path: dir, Command: "true"},
+			},
+		},
+	}
+	a := &App{
+		services:  make(map[string]*service.Service),
+		config:    &config.Config{Groups: groups},
+		ports:     portalloc.NewAllocator(portalloc.DefaultRangeStart, portalloc.DefaultRangeEnd),
+		redaction: redact.NewPolicy(nil, nil),
+		groups:    group.NewManager(groups),
+	}
+	a.applyServices(nil)
+	if got := a.services["api"].Env()["GREETING"]; got != "hello" {
+		t.Fatalf("got GREETING=%q, want hello", got)
+	}
+
+	// Like reloadConfig, take the previous services after the edit is on disk
+	if err := os.WriteFile(envFile, []byte("GREETING=bye\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	previous := a.groups.GetGroupServices()
+	a.groups = group.NewManager(groups)
+	report, _ := a.applyServices(previous)
+
+	if got := a.services["api"].Env()["GREETING"]; got != "bye" {
+		t.Errorf("got GREETING=%q, want bye", got)
+	}
+	if len(report.Updated) != 1 || report.Updated[0] != "api" {
+		t.Errorf("got updated %v, want [api]", report.Updated)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/service"
+)
+
+// EnvVariable is a variable of a service's effective environment
+type EnvVariable struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // The layer the value comes from, e.g. "global", "group", "profile staging", "launch profile https", "service" or an env file
+}
+
+// PreviewEnv returns the environment a service with the given config would
+// start with in the group, sorted by name. Ports allocated at start and
+// ${...} references are not resolved.
+func (a *App) PreviewEnv(groupId string, cfg ServiceConfig) ([]EnvVariable, error) {
+	a.mu.RLock()
+	layers, err := a.groups.EnvLayers(groupId)
+	a.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	name, profileEnv, err := service.LaunchProfileEnv(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if profileEnv != nil {
+		layers = append(layers, config.EnvLayer{Source: "launch profile " + name, Env: profileEnv})
+	}
+	for i, path := range cfg.EnvFilePaths() {
+		env, _, err := config.LoadEnvFiles([]string{path})
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, config.EnvLayer{Source: "service " + cfg.EnvFiles[i], Env: env})
+	}
+	layers = append(layers, config.EnvLayer{Source: "service", Env: cfg.Env, Unsets: true})
+	return resolveEnv(layers), nil
+}
+
+// ResolveEnv returns the environment of a service as of its last start or
+// config change, with the layer each variable comes from. Values have their
+// ${...} references expanded; variables set by the launcher itself, such as
+// allocated ports, are reported as coming from "ports".
+func (a *App) ResolveEnv(serviceId string) ([]EnvVariable, error) {
+	a.mu.RLock()
+	srv, exists := a.services[serviceId]
+	groupId, _ := a.groups.FindGroupByService(serviceId)
+	cfg := a.groups.GetGroups()[groupId].Services[serviceId]
+	a.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("service not found: %s", serviceId)
+	}
+
+	variables, err := a.PreviewEnv(groupId, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	env := srv.Env()
+	for i, variable := range variables {
+		if value, ok := env[variable.Key]; ok {
+			variables[i].Value = value
+			delete(env, variable.Key)
+		}
+	}
+	for key, value := range env {
+		variables = append(variables, EnvVariable{Key: key, Value: value, Source: "ports"})
+	}
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Key < variables[j].Key })
+	return variables, nil
+}
+
+// resolveEnv applies the layers in order and returns the result sorted by name
+func resolveEnv(layers []config.EnvLayer) []EnvVariable {
+	resolved := make(map[string]EnvVariable)
+	for _, layer := range layers {
+		for key, value := range layer.Env {
+			if value == "" && layer.Unsets {
+				delete(resolved, key)
+				continue
+			}
+			resolved[key] = EnvVariable{Key: key, Value: value, Source: layer.Source}
+		}
+	}
+	variables := make([]EnvVariable, 0, len(resolved))
+	for _, variable := range resolved {
+		variables = append(variables, variable)
+	}
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Key < variables[j].Key })
+	return variables
+}
+
+// GetEnvironment returns the global variables, the profiles and which profile each group uses
+func (a *App) GetEnvironment() config.EnvironmentConfig {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.config.Environment == nil {
+		return config.EnvironmentConfig{}
+	}
+	return *a.config.Environment
+}
+
+// UpdateEnvironment replaces the global variables and the profiles. Groups
+// using a profile that no longer exists fall back to no profile. Running
+// services whose environment changed are restarted.
+func (a *App) UpdateEnvironment(env config.ServiceEnv, profiles map[string]config.ServiceEnv) error {
+	a.mu.Lock()
+	var active map[string]string
+	if a.config.Environment != nil {
+		active = maps.Clone(a.config.Environment.ActiveProfiles)
+	}
+	maps.DeleteFunc(active, func(_ string, name string) bool {
+		_, exists := profiles[name]
+		return !exists
+	})
+	environment := &config.EnvironmentConfig{Env: env, Profiles: profiles, ActiveProfiles: active}
+	if errs := config.ValidateEnvironment(environment); len(errs) > 0 {
+		a.mu.Unlock()
+		return errs
+	}
+	restarts := a.applyEnvironment(environment)
+	a.mu.Unlock()
+
+	for _, restart := range restarts {
+		go restart()
+	}
+	return nil
+}
+
+// SetGroupProfile switches the profile a group uses, an empty name selects
+// none. Running services of the group whose environment changed are restarted.
+func (a *App) SetGroupProfile(groupId string, profile string) error {
+	a.mu.Lock()
+	if _, exists := a.groups.GetGroups()[groupId]; !exists {
+		a.mu.Unlock()
+		return fmt.Errorf("group not found: %s", groupId)
+	}
+	environment := config.EnvironmentConfig{}
+	if a.config.Environment != nil {
+		environment = *a.config.Environment
+	}
+	if _, exists := environment.Profiles[profile]; profile != "" && !exists {
+		a.mu.Unlock()
+		return fmt.Errorf("unknown profile %q", profile)
+	}
+	environment.ActiveProfiles = maps.Clone(environment.ActiveProfiles)
+	if environment.ActiveProfiles == nil {
+		environment.ActiveProfiles = make(map[string]string)
+	}
+	if profile == "" {
+		delete(environment.ActiveProfiles, groupId)
+	} else {
+		environment.ActiveProfiles[groupId] = profile
+	}
+	restarts := a.applyEnvironment(&environment)
+	a.mu.Unlock()
+
+	for _, restart := range restarts {
+		go restart()
+	}
+	return nil
+}
+
+// applyEnvironment saves a new environment config and updates the services.
+// The restarts it returns are run after a.mu is released. The caller holds a.mu.
+func (a *App) applyEnvironment(environment *config.EnvironmentConfig) []func() {
+	previous := a.groups.GetGroupServices()
+	a.config.Environment = environment
+	a.groups.SetEnvironment(environment)
+	a.saveConfig()
+	_, restarts := a.applyServices(previous)
+	return restarts
+}
@@ -2,16 +2,18 @@ import { ref, computed, onMounted } from 'vue'
 import { defineStore } from "pinia";
 import { MAX_LOGS } from "@/constants";
 import type { ServiceConfig, ServiceInfo } from "@/types/service";
-import type { ClientServiceInfo, ClientLogEntry, ScrollPosition, ClientGroupInfo } from "@/types/client";
-import { GetServices, GetGroups, AddGroup, UpdateGroup, AddServiceToGroup, UpdateServiceInGroup, ImportSLN, ImportProject, AddService, UpdateService, StartService, StartServiceWithoutBuild, StopService, ClearLogs, ReloadServices, DeleteService, StartGroup, Browse } from '../../wailsjs/go/main/App.js'
+import type { ClientServiceInfo, ClientLogEntry, ScrollPosition, ClientGroupInfo, PortConflictPrompt, EnvVar } from "@/types/client";
+import { GetServices, GetGroups, AddGroup, UpdateGroup, AddServiceToGroup, UpdateServiceInGroup, ImportSLN, ImportProject, AddService, UpdateService, StartService, StartServiceWithoutBuild, StopService, ClearLogs, ReloadServices, ResolvePortConflict, DeleteService, StartGroup, Browse, GetProxyConfig, GetProxyStatus, UpdateProxyConfig, ExportCACertificate, GetTraffic, ClearTraffic, ExportTrafficHAR, GetProjects, AddProject, RemoveProject, GetConfigStatus, ListConfigBackups, RestoreConfigBackup, ValidateService, PreviewEnv, ResolveEnv, GetEnvironment, UpdateEnvironment, SetGroupProfile, GetSecretsStatus, SetSecret, DeleteSecret, ListSecrets, GetRedaction, UpdateRedaction, GetLaunchProfiles, PreviewSolution, PreviewSync, SyncGroup, PreviewWorkspace, ImportWorkspace, GetPackageScripts, ImportCompose, GetComposeServices, ImportProcfile, ExportProcfile } from '../../wailsjs/go/main/App.js'
 import { EventsOn } from '../../wailsjs/runtime/runtime.js'
-import { process } from 'wailsjs/go/models.js';
+import { config, main, process } from 'wailsjs/go/models.js';
 
 function parseReadLogs(serviceName: string): Set<string> {
   const stored = localStorage.getItem(`readLogs_${serviceName}`);
   return new Set(JSON.parse(stored || "[]"));
 }
 
+const secretRefPattern = /^\$\{secret:([^}]+)\}$/;
+
 function transformLogEntry(log: process.LogEntry): ClientLogEntry {
   const lines = log.message.split(/\n|\. /);
   return {
@@ -35,6 +37,11 @@ export const useServicesStore = defineStore("services", () => {
     selectedGroupId.value ? groups.value[selectedGroupId.value] : null
   );
   const readLogs = ref<Record<string, Set<string>>>({});
+  const portConflicts = ref<PortConflictPrompt[]>([]);
+  const configStatus = ref<main.ConfigStatus | null>(null);
+  const environment = ref<config.EnvironmentConfig>({});
+  const configReload = ref<main.ConfigReload | null>(null);
+  let configReloadTimer: ReturnType<typeof setTimeout> | undefined;
 
   function mapToClientServiceInfo(service: ServiceInfo): ClientServiceInfo {
     return {
@@ -64,10 +71,15 @@ export const useServicesStore = defineStore("services", () => {
       mappedGroups[id] = {
         name: group.name,
         env: group.env,
+        envFiles: group.envFiles,
         services: groupServices,
+        project: group.project,
+        solution: group.solution,
       };
     }
     groups.value = mappedGroups;
+    environment.value = await GetEnvironment();
+    configStatus.value = await GetConfigStatus();
   }
 
   function isLogRead(id: string, timestamp: string) {
@@ -96,13 +108,13 @@ export const useServicesStore = defineStore("services", () => {
     ).length;
   }
 
-  async function addGroup(name: string, env: Record<string, string>) {
-    await AddGroup(name, env);
+  async function addGroup(name: string, env: Record<string, string>, envFiles: string[]) {
+    await AddGroup(name, env, envFiles);
     await loadAll();
   }
 
-  async function updateGroup(id: string, name: string, env: Record<string, string>) {
-    await UpdateGroup(id, name, env);
+  async function updateGroup(id: string, name: string, env: Record<string, string>, envFiles: string[]) {
+    await UpdateGroup(id, name, env, envFiles);
     await loadAll();
   }
 
@@ -116,13 +128,83 @@ export const useServicesStore = defineStore("services", () => {
     await loadAll();
   }
 
-  async function importSLN(slnPath: string) {
-    await ImportSLN(slnPath);
+  async function previewSolution(slnPath: string) {
+    return await PreviewSolution(slnPath);
+  }
+
+  // importSLN imports the selected project files, all runnable ones without a selection.
+  // Like the other imports it returns the problems of services that cannot start.
+  async function importSLN(slnPath: string, projectPaths?: string[]) {
+    const problems = await ImportSLN(slnPath, projectPaths ?? []);
+    await loadAll();
+    return problems;
+  }
+
+  async function previewWorkspace(path: string) {
+    return await PreviewWorkspace(path);
+  }
+
+  // importWorkspace imports the selected package directories with the script each runs, all with a dev script without a selection
+  async function importWorkspace(path: string, scripts?: Record<string, string>) {
+    const problems = await ImportWorkspace(path, scripts ?? {});
+    await loadAll();
+    return problems;
+  }
+
+  async function importCompose(path: string) {
+    const problems = await ImportCompose(path);
     await loadAll();
+    return problems;
   }
 
-  async function importProject(groupId: string, path: string, projectType: string) {
-    await ImportProject(groupId, path, projectType);
+  async function importProcfile(path: string) {
+    const problems = await ImportProcfile(path);
+    await loadAll();
+    return problems;
+  }
+
+  // exportProcfile asks where to save the group's Procfile, returns the path or "" when cancelled
+  async function exportProcfile(groupId: string): Promise<string> {
+    return await ExportProcfile(groupId, "");
+  }
+
+  async function getComposeServices(path: string, file: string) {
+    return await GetComposeServices(path, file);
+  }
+
+  async function getPackageScripts(path: string) {
+    return await GetPackageScripts(path);
+  }
+
+  async function previewSync(groupId: string) {
+    return await PreviewSync(groupId);
+  }
+
+  async function syncGroup(groupId: string, addPaths: string[], removeIds: string[]) {
+    await SyncGroup(groupId, addPaths, removeIds);
+    await loadAll();
+  }
+
+  async function getProjects() {
+    return await GetProjects();
+  }
+
+  async function addProject(path: string) {
+    try {
+      return await AddProject(path);
+    } finally {
+      await loadAll();
+    }
+  }
+
+  async function removeProject(root: string) {
+    await RemoveProject(root);
+    await loadAll();
+  }
+
+  // importProject imports a project file, an npm project runs the given script or its dev script
+  async function importProject(groupId: string, path: string, projectType: string, script = "") {
+    await ImportProject(groupId, path, projectType, script);
     await loadAll();
   }
 
@@ -145,6 +227,87 @@ export const useServicesStore = defineStore("services", () => {
     }
   }
 
+  async function validateService(groupId: string, serviceId: string, config: ServiceConfig) {
+    return await ValidateService(groupId, serviceId, config);
+  }
+
+  async function previewEnv(groupId: string, config: ServiceConfig) {
+    return await PreviewEnv(groupId, config);
+  }
+
+  async function resolveEnv(id: string) {
+    return await ResolveEnv(id);
+  }
+
+  async function updateEnvironment(env: Record<string, string>, profiles: Record<string, Record<string, string>>) {
+    await UpdateEnvironment(env, profiles);
+    await loadAll();
+  }
+
+  async function setGroupProfile(groupId: string, profile: string) {
+    await SetGroupProfile(groupId, profile);
+    await loadAll();
+  }
+
+  // toEnvVars turns an env into editable rows, references to stored secrets become secret rows
+  function toEnvVars(env: Record<string, string> | undefined): EnvVar[] {
+    return Object.entries(env || {}).map(([key, value], index) => {
+      const match = secretRefPattern.exec(value);
+      return match ? { index, key, value: "", secret: true, secretName: match[1] } : { index, key, value };
+    });
+  }
+
+  // fromEnvVars builds the env to save. New values of secret rows are replaced
+  // by references and returned separately, to be stored with storeSecrets.
+  function fromEnvVars(scope: string, vars: EnvVar[]) {
+    const env: Record<string, string> = {};
+    const secrets: Record<string, string> = {};
+    for (const item of vars) {
+      const key = item.key.trim();
+      if (!key) continue;
+      if (!item.secret) {
+        env[key] = item.value;
+      } else if (item.value !== "") {
+        const name = item.secretName ?? `${scope}/${key}`;
+        secrets[name] = item.value;
+        env[key] = `\${secret:${name}}`;
+      } else {
+        env[key] = item.secretName ? `\${secret:${item.secretName}}` : "";
+      }
+    }
+    return { env, secrets };
+  }
+
+  async function storeSecrets(secrets: Record<string, string>) {
+    for (const [name, value] of Object.entries(secrets)) {
+      await SetSecret(name, value);
+    }
+  }
+
+  async function getSecretsStatus() {
+    return await GetSecretsStatus();
+  }
+
+  async function listSecrets() {
+    return await ListSecrets();
+  }
+
+  async function deleteSecret(name: string) {
+    await DeleteSecret(name);
+  }
+
+  async function getLaunchProfiles(path: string) {
+    return await GetLaunchProfiles(path);
+  }
+
+  async function getRedaction() {
+    return await GetRedaction();
+  }
+
+  async function updateRedaction(redaction: config.RedactionConfig) {
+    await UpdateRedaction(redaction);
+  }
+
   async function updateService(id: string, config: ServiceConfig) {
     await UpdateService(id, config);
     // Update local
@@ -153,6 +316,15 @@ export const useServicesStore = defineStore("services", () => {
       name: config.name,
       path: config.path,
       env: config.env,
+      envFiles: config.envFiles,
+      launchProfile: config.launchProfile,
+      tags: config.tags,
+      packageManager: config.packageManager,
+      script: config.script,
+      args: config.args,
+      composeFile: config.composeFile,
+      composeService: config.composeService,
+      command: config.command,
       type: config.type,
     };
   }
@@ -179,10 +351,52 @@ export const useServicesStore = defineStore("services", () => {
           }
           break;
         }
+        case "portConflict": {
+          portConflicts.value = portConflicts.value.filter(
+            (prompt) => prompt.serviceId !== msg.serviceId
+          );
+          portConflicts.value.push({
+            serviceId: msg.serviceId,
+            conflicts: msg.data.conflicts,
+            withoutBuild: msg.data.withoutBuild,
+            canReassign: msg.data.canReassign,
+          });
+          break;
+        }
+        case "configStatus": {
+          configStatus.value = msg.data;
+          break;
+        }
+        case "configReloaded": {
+          // Errors stay visible through configStatus, summaries fade out
+          configReload.value = msg.data.error ? null : msg.data;
+          clearTimeout(configReloadTimer);
+          configReloadTimer = setTimeout(() => (configReload.value = null), 6000);
+          loadAll();
+          break;
+        }
       }
     });
   }
 
+  async function resolvePortConflict(serviceId: string, action: "kill" | "reassign" | "abort") {
+    const prompt = portConflicts.value.find((p) => p.serviceId === serviceId);
+    portConflicts.value = portConflicts.value.filter((p) => p.serviceId !== serviceId);
+    const serviceRef = services.value[serviceId];
+    if (serviceRef) {
+      serviceRef.status = action === "abort" ? "stopped" : "starting";
+    }
+
+    try {
+      await ResolvePortConflict(serviceId, action, prompt?.withoutBuild ?? false);
+    } catch (error) {
+      if (serviceRef) {
+        serviceRef.status = "error";
+      }
+      console.error("Failed to resolve port conflict:", error);
+    }
+  }
+
   async function startService(id: string) {
     const serviceRef = services.value[id];
     if (serviceRef) {
@@ -280,6 +494,44 @@ export const useServicesStore = defineStore("services", () => {
     return await Browse(title, filterName, pattern);
   }
 
+  async function getProxyConfig() {
+    return await GetProxyConfig();
+  }
+
+  async function getProxyStatus() {
+    return await GetProxyStatus();
+  }
+
+  async function updateProxyConfig(proxyConfig: config.ProxyConfig) {
+    await UpdateProxyConfig(proxyConfig);
+    await loadAll();
+  }
+
+  async function exportCACertificate(): Promise<string> {
+    return await ExportCACertificate("");
+  }
+
+  async function getTraffic(serviceId: string) {
+    return await GetTraffic(serviceId);
+  }
+
+  async function clearTraffic(serviceId: string) {
+    await ClearTraffic(serviceId);
+  }
+
+  async function exportTrafficHAR(serviceId: string): Promise<string> {
+    return await ExportTrafficHAR(serviceId, "");
+  }
+
+  async function listConfigBackups() {
+    return await ListConfigBackups();
+  }
+
+  async function restoreConfigBackup(name: string) {
+    await RestoreConfigBackup(name);
+    await loadAll();
+  }
+
   function saveScrollPosition(serviceId: string, position: ScrollPosition | undefined) {
     scrollPositions.value[serviceId] = position;
   }
@@ -305,11 +557,36 @@ export const useServicesStore = defineStore("services", () => {
     getUnreadErrorCount,
     addService,
     updateService,
+    validateService,
+    previewEnv,
+    resolveEnv,
+    environment,
+    updateEnvironment,
+    setGroupProfile,
+    toEnvVars,
+    fromEnvVars,
+    storeSecrets,
+    getSecretsStatus,
+    listSecrets,
+    deleteSecret,
+    getRedaction,
+    getLaunchProfiles,
+    updateRedaction,
     addGroup,
     updateGroup,
     addServiceToGroup,
     updateServiceInGroup,
     importSLN,
+    previewSolution,
+    previewSync,
+    previewWorkspace,
+    getPackageScripts,
+    importCompose,
+    importProcfile,
+    exportProcfile,
+    getComposeServices,
+    importWorkspace,
+    syncGroup,
     clearLogs,
     reloadConfig,
     deleteService,
@@ -317,8 +594,24 @@ export const useServicesStore = defineStore("services", () => {
     browse,
     loadAll,
     importProject,
+    getProjects,
+    addProject,
+    removeProject,
+    configStatus,
+    configReload,
+    listConfigBackups,
+    restoreConfigBackup,
     saveScrollPosition,
     getScrollPosition,
+    portConflicts,
+    resolvePortConflict,
+    getProxyConfig,
+    getProxyStatus,
+    updateProxyConfig,
+    exportCACertificate,
+    getTraffic,
+    clearTraffic,
+    exportTrafficHAR,
   };
 });
 
@@ -19,5 +19,30 @@ export interface ScrollPosition {
 export interface ClientGroupInfo {
   name: string;
   env: Record<string, string>;
+  envFiles?: string[];
   services: Record<string, ClientServiceInfo>;
+  project?: string;
+  solution?: string; // Solution file the group was imported from
+}
+
+export interface EnvVar {
+  index: number;
+  key: string;
+  value: string;
+  secret?: boolean;
+  secretName?: string; // Name of the stored secret the variable refers to
+}
+
+export interface PortConflict {
+  port: number;
+  pid: string;
+  cmd: string;
+  user: string;
+}
+
+export interface PortConflictPrompt {
+  serviceId: string;
+  conflicts: PortConflict[];
+  withoutBuild: boolean;
+  canReassign: boolean;
 }
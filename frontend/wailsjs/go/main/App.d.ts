@@ -1,10 +1,14 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {main} from '../models';
 import {process} from '../models';
 import {config} from '../models';
 import {service} from '../models';
+import {traffic} from '../models';
 
-export function AddGroup(arg1:string,arg2:process.ServiceEnv):Promise<string>;
+export function AddGroup(arg1:string,arg2:process.ServiceEnv,arg3:Array<string>):Promise<string>;
+
+export function AddProject(arg1:string):Promise<string>;
 
 export function AddService(arg1:config.ServiceConfig):Promise<service.Service>;
 
@@ -14,22 +18,84 @@ export function Browse(arg1:string,arg2:string,arg3:string):Promise<string>;
 
 export function ClearLogs(arg1:string):Promise<void>;
 
+export function ClearTraffic(arg1:string):Promise<void>;
+
+export function DeleteSecret(arg1:string):Promise<void>;
+
 export function DeleteService(arg1:string):Promise<void>;
 
 export function EmitToFrontend(arg1:string,arg2:string,arg3:any):Promise<void>;
 
+export function ExportCACertificate(arg1:string):Promise<string>;
+
+export function ExportProcfile(arg1:string,arg2:string):Promise<string>;
+
+export function ExportTrafficHAR(arg1:string,arg2:string):Promise<string>;
+
+export function GetComposeServices(arg1:string,arg2:string):Promise<Array<string>>;
+
+export function GetConfigStatus():Promise<main.ConfigStatus>;
+
+export function GetEnvironment():Promise<config.EnvironmentConfig>;
+
 export function GetGroups():Promise<Record<string, config.GroupConfig>>;
 
+export function GetLaunchProfiles(arg1:string):Promise<Array<main.LaunchProfile>>;
+
+export function GetPackageScripts(arg1:string):Promise<main.PackageScripts>;
+
+export function GetProjects():Promise<Array<main.ProjectInfo>>;
+
+export function GetProxyConfig():Promise<config.ProxyConfig>;
+
+export function GetProxyStatus():Promise<main.ProxyStatus>;
+
+export function GetRedaction():Promise<main.RedactionSettings>;
+
+export function GetSecretsStatus():Promise<main.SecretsStatus>;
+
 export function GetService(arg1:string):Promise<service.ServiceInfo>;
 
 export function GetServices():Promise<Record<string, service.ServiceInfo>>;
 
-export function ImportProject(arg1:string,arg2:string,arg3:string):Promise<void>;
+export function GetTraffic(arg1:string):Promise<Array<traffic.Entry>>;
+
+export function ImportCompose(arg1:string):Promise<Array<string>>;
+
+export function ImportProcfile(arg1:string):Promise<Array<string>>;
+
+export function ImportProject(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
 
-export function ImportSLN(arg1:string):Promise<void>;
+export function ImportSLN(arg1:string,arg2:Array<string>):Promise<Array<string>>;
+
+export function ImportWorkspace(arg1:string,arg2:Record<string, string>):Promise<Array<string>>;
+
+export function ListConfigBackups():Promise<Array<config.Backup>>;
+
+export function ListSecrets():Promise<Array<string>>;
+
+export function PreviewEnv(arg1:string,arg2:config.ServiceConfig):Promise<Array<main.EnvVariable>>;
+
+export function PreviewSolution(arg1:string):Promise<Array<main.SolutionProject>>;
+
+export function PreviewSync(arg1:string):Promise<main.SolutionSync>;
+
+export function PreviewWorkspace(arg1:string):Promise<main.WorkspacePreview>;
 
 export function ReloadServices():Promise<void>;
 
+export function RemoveProject(arg1:string):Promise<void>;
+
+export function ResolveEnv(arg1:string):Promise<Array<main.EnvVariable>>;
+
+export function ResolvePortConflict(arg1:string,arg2:string,arg3:boolean):Promise<void>;
+
+export function RestoreConfigBackup(arg1:string):Promise<void>;
+
+export function SetGroupProfile(arg1:string,arg2:string):Promise<void>;
+
+export function SetSecret(arg1:string,arg2:string):Promise<string>;
+
 export function StartGroup(arg1:string):Promise<void>;
 
 export function StartService(arg1:string):Promise<void>;
@@ -38,8 +104,18 @@ export function StartServiceWithoutBuild(arg1:string):Promise<void>;
 
 export function StopService(arg1:string):Promise<void>;
 
-export function UpdateGroup(arg1:string,arg2:string,arg3:process.ServiceEnv):Promise<void>;
+export function SyncGroup(arg1:string,arg2:Array<string>,arg3:Array<string>):Promise<void>;
+
+export function UpdateEnvironment(arg1:process.ServiceEnv,arg2:Record<string, process.ServiceEnv>):Promise<void>;
+
+export function UpdateGroup(arg1:string,arg2:string,arg3:process.ServiceEnv,arg4:Array<string>):Promise<void>;
+
+export function UpdateProxyConfig(arg1:config.ProxyConfig):Promise<void>;
+
+export function UpdateRedaction(arg1:config.RedactionConfig):Promise<void>;
 
 export function UpdateService(arg1:string,arg2:config.ServiceConfig):Promise<service.Service>;
 
 export function UpdateServiceInGroup(arg1:string,arg2:string,arg3:config.ServiceConfig):Promise<void>;
+
+export function ValidateService(arg1:string,arg2:string,arg3:config.ServiceConfig):Promise<Array<config.FieldError>>;
@@ -2,8 +2,12 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function AddGroup(arg1, arg2) {
-  return window['go']['main']['App']['AddGroup'](arg1, arg2);
+export function AddGroup(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AddGroup'](arg1, arg2, arg3);
+}
+
+export function AddProject(arg1) {
+  return window['go']['main']['App']['AddProject'](arg1);
 }
 
 export function AddService(arg1) {
@@ -22,6 +26,14 @@ export function ClearLogs(arg1) {
   return window['go']['main']['App']['ClearLogs'](arg1);
 }
 
+export function ClearTraffic(arg1) {
+  return window['go']['main']['App']['ClearTraffic'](arg1);
+}
+
+export function DeleteSecret(arg1) {
+  return window['go']['main']['App']['DeleteSecret'](arg1);
+}
+
 export function DeleteService(arg1) {
   return window['go']['main']['App']['DeleteService'](arg1);
 }
@@ -30,10 +42,62 @@ export function EmitToFrontend(arg1, arg2, arg3) {
   return window['go']['main']['App']['EmitToFrontend'](arg1, arg2, arg3);
 }
 
+export function ExportCACertificate(arg1) {
+  return window['go']['main']['App']['ExportCACertificate'](arg1);
+}
+
+export function ExportProcfile(arg1, arg2) {
+  return window['go']['main']['App']['ExportProcfile'](arg1, arg2);
+}
+
+export function ExportTrafficHAR(arg1, arg2) {
+  return window['go']['main']['App']['ExportTrafficHAR'](arg1, arg2);
+}
+
+export function GetComposeServices(arg1, arg2) {
+  return window['go']['main']['App']['GetComposeServices'](arg1, arg2);
+}
+
+export function GetConfigStatus() {
+  return window['go']['main']['App']['GetConfigStatus']();
+}
+
+export function GetEnvironment() {
+  return window['go']['main']['App']['GetEnvironment']();
+}
+
 export function GetGroups() {
   return window['go']['main']['App']['GetGroups']();
 }
 
+export function GetLaunchProfiles(arg1) {
+  return window['go']['main']['App']['GetLaunchProfiles'](arg1);
+}
+
+export function GetPackageScripts(arg1) {
+  return window['go']['main']['App']['GetPackageScripts'](arg1);
+}
+
+export function GetProjects() {
+  return window['go']['main']['App']['GetProjects']();
+}
+
+export function GetProxyConfig() {
+  return window['go']['main']['App']['GetProxyConfig']();
+}
+
+export function GetProxyStatus() {
+  return window['go']['main']['App']['GetProxyStatus']();
+}
+
+export function GetRedaction() {
+  return window['go']['main']['App']['GetRedaction']();
+}
+
+export function GetSecretsStatus() {
+  return window['go']['main']['App']['GetSecretsStatus']();
+}
+
 export function GetService(arg1) {
   return window['go']['main']['App']['GetService'](arg1);
 }
@@ -42,18 +106,82 @@ export function GetServices() {
   return window['go']['main']['App']['GetServices']();
 }
 
-export function ImportProject(arg1, arg2, arg3) {
-  return window['go']['main']['App']['ImportProject'](arg1, arg2, arg3);
+export function GetTraffic(arg1) {
+  return window['go']['main']['App']['GetTraffic'](arg1);
+}
+
+export function ImportCompose(arg1) {
+  return window['go']['main']['App']['ImportCompose'](arg1);
+}
+
+export function ImportProcfile(arg1) {
+  return window['go']['main']['App']['ImportProcfile'](arg1);
+}
+
+export function ImportProject(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ImportProject'](arg1, arg2, arg3, arg4);
+}
+
+export function ImportSLN(arg1, arg2) {
+  return window['go']['main']['App']['ImportSLN'](arg1, arg2);
+}
+
+export function ImportWorkspace(arg1, arg2) {
+  return window['go']['main']['App']['ImportWorkspace'](arg1, arg2);
 }
 
-export function ImportSLN(arg1) {
-  return window['go']['main']['App']['ImportSLN'](arg1);
+export function ListConfigBackups() {
+  return window['go']['main']['App']['ListConfigBackups']();
+}
+
+export function ListSecrets() {
+  return window['go']['main']['App']['ListSecrets']();
+}
+
+export function PreviewEnv(arg1, arg2) {
+  return window['go']['main']['App']['PreviewEnv'](arg1, arg2);
+}
+
+export function PreviewSolution(arg1) {
+  return window['go']['main']['App']['PreviewSolution'](arg1);
+}
+
+export function PreviewSync(arg1) {
+  return window['go']['main']['App']['PreviewSync'](arg1);
+}
+
+export function PreviewWorkspace(arg1) {
+  return window['go']['main']['App']['PreviewWorkspace'](arg1);
 }
 
 export function ReloadServices() {
   return window['go']['main']['App']['ReloadServices']();
 }
 
+export function RemoveProject(arg1) {
+  return window['go']['main']['App']['RemoveProject'](arg1);
+}
+
+export function ResolveEnv(arg1) {
+  return window['go']['main']['App']['ResolveEnv'](arg1);
+}
+
+export function ResolvePortConflict(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ResolvePortConflict'](arg1, arg2, arg3);
+}
+
+export function RestoreConfigBackup(arg1) {
+  return window['go']['main']['App']['RestoreConfigBackup'](arg1);
+}
+
+export function SetGroupProfile(arg1, arg2) {
+  return window['go']['main']['App']['SetGroupProfile'](arg1, arg2);
+}
+
+export function SetSecret(arg1, arg2) {
+  return window['go']['main']['App']['SetSecret'](arg1, arg2);
+}
+
 export function StartGroup(arg1) {
   return window['go']['main']['App']['StartGroup'](arg1);
 }
@@ -70,8 +198,24 @@ export function StopService(arg1) {
   return window['go']['main']['App']['StopService'](arg1);
 }
 
-export function UpdateGroup(arg1, arg2, arg3) {
-  return window['go']['main']['App']['UpdateGroup'](arg1, arg2, arg3);
+export function SyncGroup(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SyncGroup'](arg1, arg2, arg3);
+}
+
+export function UpdateEnvironment(arg1, arg2) {
+  return window['go']['main']['App']['UpdateEnvironment'](arg1, arg2);
+}
+
+export function UpdateGroup(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['UpdateGroup'](arg1, arg2, arg3, arg4);
+}
+
+export function UpdateProxyConfig(arg1) {
+  return window['go']['main']['App']['UpdateProxyConfig'](arg1);
+}
+
+export function UpdateRedaction(arg1) {
+  return window['go']['main']['App']['UpdateRedaction'](arg1);
 }
 
 export function UpdateService(arg1, arg2) {
@@ -81,3 +225,7 @@ export function UpdateService(arg1, arg2) {
 export function UpdateServiceInGroup(arg1, arg2, arg3) {
   return window['go']['main']['App']['UpdateServiceInGroup'](arg1, arg2, arg3);
 }
+
+export function ValidateService(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ValidateService'](arg1, arg2, arg3);
+}
@@ -1,10 +1,153 @@
 export namespace config {
 	
+	export interface Backup {
+	    name: string;
+	    time: string;
+	    size: number;
+	}
+	export interface EnvironmentConfig {
+	    env?: Record<string, string>;
+	    profiles?: Record<string, Record<string, string>>;
+	    activeProfiles?: Record<string, string>;
+	}
+	export interface FieldError {
+	    field: string;
+	    message: string;
+	}
+	export interface ProxyConfig {
+	    address?: string;
+	    https?: boolean;
+	    httpsAddress?: string;
+	}
+	export interface ProxyRoute {
+	    host?: string;
+	    pathPrefix?: string;
+	    stripPrefix?: boolean;
+	    record?: boolean;
+	}
+	export interface RedactionConfig {
+	    sensitiveEnv?: string[];
+	    patterns?: string[];
+	    noDefaults?: boolean;
+	}
+	export interface PortSpec {
+	    name: string;
+	    env?: string[];
+	}
 	export interface ServiceConfig {
 	    name: string;
 	    path: string;
 	    env: Record<string, string>;
 	    type: string;
+	    ports?: number[];
+	    namedPorts?: PortSpec[];
+	    proxy?: ProxyRoute;
+	    dependsOn?: string[];
+	    envFiles?: string[];
+	    launchProfile?: string;
+	    tags?: string[];
+	    packageManager?: string;
+	    script?: string;
+	    args?: string[];
+	    composeFile?: string;
+	    composeService?: string;
+	    command?: string;
+	}
+	export interface GroupConfig {
+	    name: string;
+	    env: Record<string, string>;
+	    services: Record<string, ServiceConfig>;
+	    envFiles?: string[];
+	    project?: string;
+	    solution?: string;
+	}
+
+}
+
+export namespace main {
+	
+	export interface ConfigReload {
+	    added?: string[];
+	    removed?: string[];
+	    restarted?: string[];
+	    updated?: string[];
+	    error?: string;
+	}
+	export interface ConfigStatus {
+	    path: string;
+	    format: string;
+	    version: number;
+	    loadError?: string;
+	    saveError?: string;
+	    watchError?: string;
+	}
+	export interface EnvVariable {
+	    key: string;
+	    value: string;
+	    source: string;
+	}
+	export interface LaunchProfile {
+	    name: string;
+	    applicationUrl?: string;
+	    commandLineArgs?: string;
+	    env?: Record<string, string>;
+	    default: boolean;
+	}
+	export interface PackageScripts {
+	    scripts: Record<string, string>;
+	    devScript?: string;
+	    packageManager: string;
+	}
+	export interface ProjectInfo {
+	    root: string;
+	    file: string;
+	    discovered: boolean;
+	    error?: string;
+	}
+	export interface ProxyStatus {
+	    address: string;
+	    tlsAddress?: string;
+	    running: boolean;
+	    error?: string;
+	}
+	export interface RedactionSettings {
+	    config: config.RedactionConfig;
+	    defaultSensitiveEnv: string[];
+	    defaultPatterns: string[];
+	}
+	export interface SolutionProject {
+	    name: string;
+	    path: string;
+	    folder?: string;
+	    kind: string;
+	    runnable: boolean;
+	    reason: string;
+	}
+	export interface SolutionSync {
+	    solution: string;
+	    added: SolutionProject[];
+	    removed: string[];
+	    unchanged: string[];
+	}
+	export interface SecretsStatus {
+	    backend: string;
+	    warning?: string;
+	}
+	export interface WorkspacePackage {
+	    name: string;
+	    dir: string;
+	    folder?: string;
+	    script?: string;
+	    scripts: string[];
+	    app: boolean;
+	    reason: string;
+	}
+	export interface WorkspacePreview {
+	    root: string;
+	    name: string;
+	    packageManager: string;
+	    tool?: string;
+	    packages: WorkspacePackage[];
 	}
 
 }
@@ -40,6 +183,47 @@ export namespace service {
 	    env: Record<string, string>;
 	    inheritedEnv: Record<string, string>;
 	    type: string;
+	    ports?: number[];
+	    namedPorts?: config.PortSpec[];
+	    allocatedPorts?: Record<string, number>;
+	    proxy?: config.ProxyRoute;
+	    proxyUrl?: string;
+	    dependsOn?: string[];
+	    envFiles?: string[];
+	    launchProfile?: string;
+	    tags?: string[];
+	    packageManager?: string;
+	    script?: string;
+	    args?: string[];
+	    composeFile?: string;
+	    composeService?: string;
+	    command?: string;
+	    problems?: config.FieldError[];
+	}
+
+}
+
+export namespace traffic {
+	
+	export interface Entry {
+	    id: string;
+	    serviceId: string;
+	    startedAt: string;
+	    durationMs: number;
+	    method: string;
+	    url: string;
+	    path: string;
+	    proto: string;
+	    status: number;
+	    requestHeaders: Record<string, string[]>;
+	    responseHeaders: Record<string, string[]>;
+	    requestBody?: string;
+	    responseBody?: string;
+	    requestSize: number;
+	    responseSize: number;
+	    requestTruncated?: boolean;
+	    responseTruncated?: boolean;
+	    upgraded?: boolean;
 	}
 
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"wails-launcher/pkg/launchsettings"
+)
+
+// LaunchProfile is a profile of a project's launchSettings.json that dotnet run can use
+type LaunchProfile struct {
+	Name            string            `json:"name"`
+	ApplicationURL  string            `json:"applicationUrl,omitempty"`
+	CommandLineArgs string            `json:"commandLineArgs,omitempty"`
+	Env             map[string]string `json:"env,omitempty"`
+	Default         bool              `json:"default"` // Picked by dotnet run when no profile is selected
+}
+
+// GetLaunchProfiles returns the launch profiles of the dotnet project in a
+// directory, in file order. A project without launchSettings.json has none.
+func (a *App) GetLaunchProfiles(path string) ([]LaunchProfile, error) {
+	settings, err := launchsettings.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	defaultName := ""
+	if profile := settings.DefaultProfile(); profile != nil {
+		defaultName = profile.Name
+	}
+	profiles := []LaunchProfile{}
+	for _, profile := range settings.ProjectProfiles() {
+		profiles = append(profiles, LaunchProfile{
+			Name:            profile.Name,
+			ApplicationURL:  profile.Profile.ApplicationURL,
+			CommandLineArgs: profile.Profile.CommandLineArgs,
+			Env:             profile.Profile.EnvironmentVariables,
+			Default:         profile.Name == defaultName,
+		})
+	}
+	return profiles, nil
+}
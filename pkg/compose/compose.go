@@ -0,0 +1,197 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the compose files looked up in a directory, in the order
+// docker compose prefers them
+var FileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// Service is a service of a compose file
+type Service struct {
+	Name        string
+	Image       string
+	Build       bool     // Built from a Dockerfile rather than pulled
+	Ports       []int    // Published host ports
+	PortVars    []string // Variables the published ports refer to, like PORT
+	DependsOn   []string // Compose services started first
+	Healthcheck bool     // Reports its health, which Running waits for
+}
+
+// Project is the list of services of a compose file
+type Project struct {
+	Name     string // Project name, the name field or the directory name
+	File     string // Absolute path of the compose file
+	Services []Service
+}
+
+// Find returns the compose file of a directory
+func Find(dir string) (string, error) {
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no compose file in %s, expected one of %s", dir, strings.Join(FileNames, ", "))
+}
+
+// file holds the parts of a compose file the launcher looks at
+type file struct {
+	Name     string `yaml:"name"`
+	Services map[string]struct {
+		Image       string      `yaml:"image"`
+		Build       yaml.Node   `yaml:"build"`
+		Ports       []yaml.Node `yaml:"ports"`
+		DependsOn   yaml.Node   `yaml:"depends_on"` // A list of names or a map by name
+		Healthcheck *struct {
+			Disable bool `yaml:"disable"`
+		} `yaml:"healthcheck"`
+	} `yaml:"services"`
+}
+
+// Load reads a compose file, or the compose file of a directory. Services
+// are sorted by name.
+func Load(path string) (*Project, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		if path, err = Find(path); err != nil {
+			return nil, err
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc file
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid compose file %s: %w", path, err)
+	}
+
+	project := &Project{Name: doc.Name, File: path}
+	if project.Name == "" {
+		project.Name = strings.ToLower(filepath.Base(filepath.Dir(path)))
+	}
+	for name, svc := range doc.Services {
+		service := Service{
+			Name:        name,
+			Image:       svc.Image,
+			Build:       !svc.Build.IsZero(),
+			Healthcheck: svc.Healthcheck != nil && !svc.Healthcheck.Disable,
+		}
+		for _, port := range svc.Ports {
+			service.Ports = append(service.Ports, publishedPorts(port)...)
+			service.PortVars = append(service.PortVars, portVars(port)...)
+		}
+		service.DependsOn = dependencies(svc.DependsOn)
+		project.Services = append(project.Services, service)
+	}
+	sort.Slice(project.Services, func(i, j int) bool { return project.Services[i].Name < project.Services[j].Name })
+	return project, nil
+}
+
+// Service returns the service with the given name, nil without one
+func (p *Project) Service(name string) *Service {
+	for i := range p.Services {
+		if p.Services[i].Name == name {
+			return &p.Services[i]
+		}
+	}
+	return nil
+}
+
+// defaultRegex matches a variable with a default, ${PORT:-5432} or ${PORT-5432}
+var defaultRegex = regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*:?-([^}]*)\}`)
+
+// publishedPorts returns the host ports of a ports entry: "8080:80",
+// "127.0.0.1:8080:80", "8080-8081:80-81" or the long syntax with published.
+// Entries without a host port publish on a random one and are skipped.
+func publishedPorts(node yaml.Node) []int {
+	var published string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		value := defaultRegex.ReplaceAllString(node.Value, "$1")
+		value, _, _ = strings.Cut(value, "/") // Protocol
+		parts := strings.Split(value, ":")
+		if len(parts) < 2 {
+			return nil
+		}
+		published = parts[len(parts)-2]
+	case yaml.MappingNode:
+		var long struct {
+			Published string `yaml:"published"`
+		}
+		if err := node.Decode(&long); err != nil {
+			return nil
+		}
+		published = defaultRegex.ReplaceAllString(long.Published, "$1")
+	}
+
+	first, last, isRange := strings.Cut(published, "-")
+	start, err := strconv.Atoi(first)
+	if err != nil {
+		return nil
+	}
+	end := start
+	if isRange {
+		if end, err = strconv.Atoi(last); err != nil || end < start {
+			return nil
+		}
+	}
+	var ports []int
+	for port := start; port <= end; port++ {
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// varRegex matches a variable reference, $PORT or ${PORT...}
+var varRegex = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+
+// portVars returns the variables a ports entry refers to
+func portVars(node yaml.Node) []string {
+	value := node.Value
+	if node.Kind == yaml.MappingNode {
+		var long struct {
+			Published string `yaml:"published"`
+		}
+		if err := node.Decode(&long); err != nil {
+			return nil
+		}
+		value = long.Published
+	}
+	var names []string
+	for _, m := range varRegex.FindAllStringSubmatch(value, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// dependencies reads depends_on, a list of service names or a map by name
+func dependencies(node yaml.Node) []string {
+	var names []string
+	switch node.Kind {
+	case yaml.SequenceNode:
+		node.Decode(&names)
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			names = append(names, node.Content[i].Value)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxBackups is how many previous versions of the config file are kept
+const MaxBackups = 20
+
+// backupTimeFormat sorts lexically in time order and is safe in file names
+const backupTimeFormat = "20060102-150405.000"
+
+// Backup is a previous version of the config file
+type Backup struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+// backupDir returns the directory backups of the config file at configPath are kept in
+func backupDir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "backups")
+}
+
+// backup copies the current config file into the backup directory and drops the oldest backups
+func backup(configPath string, data []byte) error {
+	dir := backupDir(configPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	ext := filepath.Ext(configPath)
+	base := strings.TrimSuffix(filepath.Base(configPath), ext)
+	name := fmt.Sprintf("%s-%s%s", base, time.Now().Format(backupTimeFormat), ext)
+	if err := WriteFileAtomic(filepath.Join(dir, name), data, 0644); err != nil {
+		return err
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		return err
+	}
+	for _, old := range backups[min(len(backups), MaxBackups):] {
+		os.Remove(filepath.Join(dir, old.Name))
+	}
+	return nil
+}
+
+// ListBackups returns the backups of the config file, newest first
+func ListBackups() ([]Backup, error) {
+	configPath, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(backupDir(configPath))
+	if os.IsNotExist(err) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(configPath)
+	prefix := strings.TrimSuffix(filepath.Base(configPath), ext) + "-"
+	backups := []Backup{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), filepath.Ext(name))
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Name: name, Time: t, Size: info.Size()})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+// RestoreBackup replaces the config file with a backup. The current file is
+// backed up first, so a restore can itself be undone.
+func RestoreBackup(name string) error {
+	if name != filepath.Base(name) {
+		return fmt.Errorf("invalid backup name %q", name)
+	}
+	configPath, err := Path()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(backupDir(configPath), name))
+	if err != nil {
+		return err
+	}
+
+	// Refuse backups this launcher cannot read, e.g. from a newer version
+	node, err := parse(data, filepath.Ext(name))
+	if err != nil {
+		return fmt.Errorf("backup %s is not a valid config: %w", name, err)
+	}
+	var restored Config
+	if node != nil {
+		if _, err := migrate(node); err != nil {
+			return fmt.Errorf("backup %s cannot be restored: %w", name, err)
+		}
+		if err := node.Decode(&restored); err != nil {
+			return fmt.Errorf("backup %s is not a valid config: %w", name, err)
+		}
+	}
+
+	if strings.EqualFold(filepath.Ext(name), filepath.Ext(configPath)) {
+		// Same format, restore the file byte for byte including its comments
+		if current, err := os.ReadFile(configPath); err == nil {
+			if err := backup(configPath, current); err != nil {
+				return err
+			}
+		}
+		return WriteFileAtomic(configPath, data, 0644)
+	}
+	return restored.Save()
+}
+
+// WriteFileAtomic replaces path with data so that readers and crashes only ever
+// see the old or the new content: the data is written to a temporary file in
+// the same directory, flushed to disk and renamed over path.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	// Persist the rename itself; not supported on every platform
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// saveFile writes a config file atomically, backing up the previous content when it changes
+func saveFile(path string, previous, data []byte) error {
+	if bytes.Equal(previous, data) {
+		return nil
+	}
+	if previous != nil {
+		if err := backup(path, previous); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+	return WriteFileAtomic(path, data, 0644)
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"path/filepath"
+
+	"wails-launcher/pkg/compose"
+)
+
+// ComposeFilePath returns the compose file of a compose service, the
+// configured one relative to Path or the one found in Path
+func (s ServiceConfig) ComposeFilePath() (string, error) {
+	if s.ComposeFile == "" {
+		return compose.Find(s.Path)
+	}
+	return resolvePaths([]string{s.ComposeFile}, s.Path)[0], nil
+}
+
+// validateCompose checks that the compose file exists and has the service
+func validateCompose(errs *ValidationError, svc ServiceConfig) {
+	if svc.ComposeService == "" {
+		errs.add("composeService", "is required")
+	}
+	path, err := svc.ComposeFilePath()
+	if err != nil {
+		errs.add("composeFile", "%v", err)
+		return
+	}
+	project, err := compose.Load(path)
+	if err != nil {
+		errs.add("composeFile", "%v", err)
+		return
+	}
+	if svc.ComposeService != "" && project.Service(svc.ComposeService) == nil {
+		errs.add("composeService", "%s has no service %q", filepath.Base(path), svc.ComposeService)
+	}
+}
@@ -1,7 +1,7 @@
 package config
 
 import (
-	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -11,38 +11,118 @@ import (
 // ServiceEnv represents environment variables
 type ServiceEnv = process.ServiceEnv
 
+// PortSpec declares a named port the launcher allocates when the service starts
+type PortSpec struct {
+	Name string   `json:"name" yaml:"name"`
+	Env  []string `json:"env,omitempty" yaml:"env,omitempty"` // Variables receiving the port, defaults depend on the service type
+}
+
+// ProxyRoute exposes a service through the launcher's reverse proxy
+type ProxyRoute struct {
+	Host        string `json:"host,omitempty" yaml:"host,omitempty"`             // e.g. "api.localhost"
+	PathPrefix  string `json:"pathPrefix,omitempty" yaml:"pathPrefix,omitempty"` // e.g. "/api"
+	StripPrefix bool   `json:"stripPrefix,omitempty" yaml:"stripPrefix,omitempty"`
+	Record      bool   `json:"record,omitempty" yaml:"record,omitempty"` // Capture requests for the traffic inspector
+}
+
 // ServiceConfig represents service configuration
 type ServiceConfig struct {
-	Name string     `json:"name"`
-	Path string     `json:"path"`
-	Env  ServiceEnv `json:"env"`
-	Type string     `json:"type"` // "dotnet", "npm", etc.
+	Name           string      `json:"name" yaml:"name"`
+	Path           string      `json:"path" yaml:"path"`
+	Env            ServiceEnv  `json:"env" yaml:"env"`
+	Type           string      `json:"type" yaml:"type"`                                 // "dotnet", "npm", "compose" or "command"
+	Ports          []int       `json:"ports,omitempty" yaml:"ports,omitempty"`           // Ports checked for conflicts before starting
+	NamedPorts     []PortSpec  `json:"namedPorts,omitempty" yaml:"namedPorts,omitempty"` // Ports allocated from the port range at start
+	Proxy          *ProxyRoute `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	DependsOn      []string    `json:"dependsOn,omitempty" yaml:"dependsOn,omitempty"`           // Names of services started first by StartGroup
+	EnvFiles       []string    `json:"envFiles,omitempty" yaml:"envFiles,omitempty"`             // Dotenv files relative to Path, later files win
+	LaunchProfile  string      `json:"launchProfile,omitempty" yaml:"launchProfile,omitempty"`   // Profile of Properties/launchSettings.json a dotnet service runs with, empty for the default
+	Tags           []string    `json:"tags,omitempty" yaml:"tags,omitempty"`                     // Labels for grouping in the list, e.g. the solution folder
+	PackageManager string      `json:"packageManager,omitempty" yaml:"packageManager,omitempty"` // npm, pnpm, yarn or bun for npm services, detected from the lock file when empty
+	Script         string      `json:"script,omitempty" yaml:"script,omitempty"`                 // package.json script an npm service runs, picked from the dev scripts when empty
+	Args           []string    `json:"args,omitempty" yaml:"args,omitempty"`                     // Extra arguments passed to the program after --, or to compose up
+	ComposeFile    string      `json:"composeFile,omitempty" yaml:"composeFile,omitempty"`       // Compose file relative to Path, looked up in Path when empty
+	ComposeService string      `json:"composeService,omitempty" yaml:"composeService,omitempty"` // Service of the compose file a compose service runs
+	Command        string      `json:"command,omitempty" yaml:"command,omitempty"`               // Shell command line a command service runs in Path
 }
 
 // GroupConfig represents group configuration
 type GroupConfig struct {
-	Name     string                   `json:"name"`
-	Env      ServiceEnv               `json:"env"`
-	Services map[string]ServiceConfig `json:"services"`
+	Name     string                   `json:"name" yaml:"name"`
+	Env      ServiceEnv               `json:"env" yaml:"env"`
+	Services map[string]ServiceConfig `json:"services" yaml:"services"`
+	EnvFiles []string                 `json:"envFiles,omitempty" yaml:"envFiles,omitempty"` // Dotenv files relative to the project root or the config directory
+	Project  string                   `json:"project,omitempty" yaml:"-"`                   // Root of the project file defining the group, empty for local groups
+	Solution string                   `json:"solution,omitempty" yaml:"solution,omitempty"` // Solution file the group was imported from, for syncing
+}
+
+// PortRange is the inclusive range named ports are allocated from
+type PortRange struct {
+	Start int `json:"start" yaml:"start"`
+	End   int `json:"end" yaml:"end"`
+}
+
+// ProxyConfig configures the launcher's reverse proxy
+type ProxyConfig struct {
+	Address      string `json:"address,omitempty" yaml:"address,omitempty"`           // Listen address, defaults to 127.0.0.1:8080
+	HTTPS        bool   `json:"https,omitempty" yaml:"https,omitempty"`               // Terminate TLS with certificates from the local dev CA
+	HTTPSAddress string `json:"httpsAddress,omitempty" yaml:"httpsAddress,omitempty"` // HTTPS listen address, defaults to 127.0.0.1:8443
+}
+
+// EnvironmentConfig holds the environment layers shared by the groups
+type EnvironmentConfig struct {
+	Env            ServiceEnv            `json:"env,omitempty" yaml:"env,omitempty"`                       // Variables of all groups
+	Profiles       map[string]ServiceEnv `json:"profiles,omitempty" yaml:"profiles,omitempty"`             // Named sets of variables a group can switch to
+	ActiveProfiles map[string]string     `json:"activeProfiles,omitempty" yaml:"activeProfiles,omitempty"` // Profile name by group ID
+}
+
+// RedactionConfig decides what is masked in service logs and exports, on
+// top of stored secrets
+type RedactionConfig struct {
+	SensitiveEnv []string `json:"sensitiveEnv,omitempty" yaml:"sensitiveEnv,omitempty"` // Env var names whose values are masked, * and ? match any characters
+	Patterns     []string `json:"patterns,omitempty" yaml:"patterns,omitempty"`         // Regular expressions, only the first group is masked when there is one
+	NoDefaults   bool     `json:"noDefaults,omitempty" yaml:"noDefaults,omitempty"`     // Skip the built-in names and patterns
 }
 
 // Config represents the overall configuration
 type Config struct {
-	Groups map[string]GroupConfig `json:"groups"`
+	Schema      string                 `json:"$schema,omitempty" yaml:"$schema,omitempty"` // Editor hint pointing at the JSON Schema
+	Version     int                    `json:"version" yaml:"version"`                     // Format version, see CurrentVersion
+	Groups      map[string]GroupConfig `json:"groups" yaml:"groups"`
+	PortRange   *PortRange             `json:"portRange,omitempty" yaml:"portRange,omitempty"`
+	Proxy       *ProxyConfig           `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	Environment *EnvironmentConfig     `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Redaction   *RedactionConfig       `json:"redaction,omitempty" yaml:"redaction,omitempty"`
+	Projects    []string               `json:"projects,omitempty" yaml:"projects,omitempty"` // Project roots whose .launcher.yaml is loaded
 }
 
-// getConfigPath returns the path to the services.json file
-func getConfigPath() (string, error) {
+// fileNames are the config files looked up in the config directory, in order of preference
+var fileNames = []string{"services.yaml", "services.yml", "services.toml", "services.json"}
+
+// Path returns the config file in use. LAUNCHER_CONFIG overrides the location;
+// otherwise the first existing services file in the config directory is used,
+// defaulting to services.json. The format follows the file extension.
+func Path() (string, error) {
+	if path := os.Getenv("LAUNCHER_CONFIG"); path != "" {
+		return path, nil
+	}
 	configDir, err := os.UserConfigDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(configDir, "wails-launcher", "services.json"), nil
+	dir := filepath.Join(configDir, "wails-launcher")
+	for _, name := range fileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return filepath.Join(dir, "services.json"), nil
 }
 
-// Load loads configuration from services.json
+// Load loads configuration from the config file
 func Load() (*Config, error) {
-	configPath, err := getConfigPath()
+	configPath, err := Path()
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +132,7 @@ func Load() (*Config, error) {
 		if os.IsNotExist(err) {
 			// Try to migrate from old location
 			oldData, oldErr := os.ReadFile("services.json")
-			if oldErr == nil {
+			if oldErr == nil && filepath.Ext(configPath) == ".json" {
 				// Create directory and copy file
 				dir := filepath.Dir(configPath)
 				if mkdirErr := os.MkdirAll(dir, 0755); mkdirErr != nil {
@@ -73,33 +153,69 @@ func Load() (*Config, error) {
 		}
 	}
 
+	node, err := parse(data, filepath.Ext(configPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
 	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
+	fromVersion := CurrentVersion
+	if node != nil {
+		if fromVersion, err = migrate(node); err != nil {
+			return nil, fmt.Errorf("failed to migrate %s: %w", configPath, err)
+		}
+		if err := node.Decode(&config); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", configPath, err)
+		}
 	}
 
 	if config.Groups == nil {
 		config.Groups = make(map[string]GroupConfig)
 	}
+	config.Version = CurrentVersion
+
+	if fromVersion < CurrentVersion {
+		// Keep the file as it was so a failed migration can be undone by hand
+		backupPath := fmt.Sprintf("%s.v%d.bak", configPath, fromVersion)
+		if err := os.WriteFile(backupPath, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to back up %s before migrating: %w", configPath, err)
+		}
+		if err := config.Save(); err != nil {
+			return nil, err
+		}
+	}
 
 	return &config, nil
 }
 
-// Save saves configuration to services.json
+// Save saves configuration to the config file, keeping the comments and key
+// order of the existing file
 func (c *Config) Save() error {
-	data, err := json.MarshalIndent(c, "", "  ")
+	configPath, err := Path()
 	if err != nil {
 		return err
 	}
-	configPath, err := getConfigPath()
+	ext := filepath.Ext(configPath)
+	c.Version = CurrentVersion
+	previous, readErr := os.ReadFile(configPath)
+	if ext == ".json" && c.Schema == "" {
+		c.Schema = "./" + SchemaFileName
+	}
+	data, err := Marshal(c, ext, previous)
 	if err != nil {
 		return err
 	}
+	if readErr != nil {
+		// Point editors of new files at the schema
+		data = append(schemaComment(ext), data...)
+	}
 	dir := filepath.Dir(configPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
-	return os.WriteFile(configPath, data, 0644)
+	if err := WriteSchema(dir); err != nil {
+		return err
+	}
+	return saveFile(configPath, previous, data)
 }
 
 // MigrateFromOldFormat migrates old flat service format to new grouped format
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"wails-launcher/pkg/dotenv"
+)
+
+// EnvFilePaths returns the service's dotenv files as absolute paths
+func (s ServiceConfig) EnvFilePaths() []string {
+	return resolvePaths(s.EnvFiles, s.Path)
+}
+
+// EnvFilePaths returns the group's dotenv files as absolute paths. They are
+// relative to the project root for project groups and to the directory of
+// the config file otherwise.
+func (g GroupConfig) EnvFilePaths() []string {
+	base := g.Project
+	if base == "" {
+		if path, err := Path(); err == nil {
+			base = filepath.Dir(path)
+		}
+	}
+	return resolvePaths(g.EnvFiles, base)
+}
+
+// resolvePaths makes relative paths absolute against base
+func resolvePaths(paths []string, base string) []string {
+	resolved := make([]string, len(paths))
+	for i, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(base, filepath.FromSlash(path))
+		}
+		resolved[i] = path
+	}
+	return resolved
+}
+
+// LoadEnvFiles reads dotenv files in order, later files override earlier ones.
+// Missing files are skipped and returned separately, as files like .env.local
+// are usually not checked in and may not exist on every machine.
+func LoadEnvFiles(paths []string) (env ServiceEnv, missing []string, err error) {
+	env = make(ServiceEnv)
+	for _, path := range paths {
+		values, err := dotenv.ReadFile(path)
+		if os.IsNotExist(err) {
+			missing = append(missing, path)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		for key, value := range values {
+			env[key] = value
+		}
+	}
+	return env, missing, nil
+}
+
+// EnvLayer is one source of environment variables
+type EnvLayer struct {
+	Source string // e.g. "global", "group", "profile staging" or an env file
+	Env    ServiceEnv
+	Unsets bool // An empty value removes the variable
+}
+
+// MergeLayers applies the layers in order, later layers override earlier ones
+func MergeLayers(layers []EnvLayer) ServiceEnv {
+	env := make(ServiceEnv)
+	for _, layer := range layers {
+		for key, value := range layer.Env {
+			if value == "" && layer.Unsets {
+				delete(env, key)
+			} else {
+				env[key] = value
+			}
+		}
+	}
+	return env
+}
+
+// Profile returns the active profile of a group and its variables. Unknown
+// profiles are ignored, validation reports them.
+func (e *EnvironmentConfig) Profile(groupId string) (string, ServiceEnv, bool) {
+	if e == nil {
+		return "", nil, false
+	}
+	name := e.ActiveProfiles[groupId]
+	env, exists := e.Profiles[name]
+	return name, env, name != "" && exists
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats are told apart by file extension. All of them are converted to and
+// from a yaml.Node tree, which keeps key order and comments, so a save can be
+// merged into the previous file content instead of replacing it.
+var formats = map[string]struct {
+	parse func(data []byte) (*yaml.Node, error)
+	write func(node *yaml.Node) ([]byte, error)
+}{
+	".json": {parseYAML, writeJSON}, // JSON is a subset of YAML
+	".yaml": {parseYAML, writeYAML},
+	".yml":  {parseYAML, writeYAML},
+	".toml": {parseTOML, writeTOML},
+}
+
+// Unmarshal decodes data in the format given by the file extension ext into v
+func Unmarshal(data []byte, ext string, v any) error {
+	node, err := parse(data, ext)
+	if err != nil || node == nil {
+		return err
+	}
+	return node.Decode(v)
+}
+
+// parse reads data in the format given by ext into a node tree, nil when empty
+func parse(data []byte, ext string) (*yaml.Node, error) {
+	f, ok := formats[strings.ToLower(ext)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+	return f.parse(data)
+}
+
+// Marshal encodes v in the format given by the file extension ext. When previous
+// holds the current file content, its comments and key order are kept.
+func Marshal(v any, ext string, previous []byte) ([]byte, error) {
+	f, ok := formats[strings.ToLower(ext)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+	result := &node
+	if len(bytes.TrimSpace(previous)) > 0 {
+		// A previous file that no longer parses is simply replaced
+		if old, err := f.parse(previous); err == nil && old != nil {
+			result = mergeNodes(old, &node)
+		}
+	}
+	return f.write(result)
+}
+
+// parseYAML parses YAML or JSON into a node tree, nil for an empty document
+func parseYAML(data []byte) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	if node.Kind == 0 {
+		return nil, nil
+	}
+	return &node, nil
+}
+
+// writeYAML renders a node tree as YAML
+func writeYAML(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSON renders a node tree as indented JSON
+func writeJSON(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSONValue(&buf, node, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func writeJSONValue(buf *bytes.Buffer, node *yaml.Node, indent string) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSONValue(buf, node.Content[0], indent)
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i := 0; i < len(node.Content); i += 2 {
+			key, _ := json.Marshal(node.Content[i].Value)
+			buf.WriteString(indent + "  ")
+			buf.Write(key)
+			buf.WriteString(": ")
+			if err := writeJSONValue(buf, node.Content[i+1], indent+"  "); err != nil {
+				return err
+			}
+			if i+2 < len(node.Content) {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range node.Content {
+			buf.WriteString(indent + "  ")
+			if err := writeJSONValue(buf, item, indent+"  "); err != nil {
+				return err
+			}
+			if i+1 < len(node.Content) {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!int", "!!float", "!!bool":
+			buf.WriteString(node.Value)
+		case "!!null":
+			buf.WriteString("null")
+		default:
+			value, _ := json.Marshal(node.Value)
+			buf.Write(value)
+		}
+	case yaml.AliasNode:
+		return writeJSONValue(buf, node.Alias, indent)
+	}
+	return nil
+}
+
+// mergeNodes returns updated with the comments, styles and key order of previous
+// carried over. Keys missing from updated are dropped, new keys are appended.
+func mergeNodes(previous, updated *yaml.Node) *yaml.Node {
+	if previous.Kind == yaml.DocumentNode && updated.Kind != yaml.DocumentNode {
+		if len(previous.Content) == 0 {
+			return updated
+		}
+		document := *previous
+		document.Content = []*yaml.Node{mergeNodes(previous.Content[0], updated)}
+		return &document
+	}
+	if previous.Kind != updated.Kind {
+		return withComments(updated, previous)
+	}
+
+	merged := *updated
+	merged.HeadComment = previous.HeadComment
+	merged.LineComment = previous.LineComment
+	merged.FootComment = previous.FootComment
+
+	switch updated.Kind {
+	case yaml.MappingNode:
+		merged.Style = previous.Style
+		index := make(map[string]int)
+		for i := 0; i < len(updated.Content); i += 2 {
+			index[updated.Content[i].Value] = i
+		}
+		merged.Content = nil
+		seen := make(map[string]bool)
+		for i := 0; i < len(previous.Content); i += 2 {
+			key := previous.Content[i].Value
+			j, exists := index[key]
+			if !exists {
+				continue
+			}
+			seen[key] = true
+			merged.Content = append(merged.Content,
+				withComments(updated.Content[j], previous.Content[i]),
+				mergeNodes(previous.Content[i+1], updated.Content[j+1]))
+		}
+		for i := 0; i < len(updated.Content); i += 2 {
+			if !seen[updated.Content[i].Value] {
+				merged.Content = append(merged.Content, updated.Content[i], updated.Content[i+1])
+			}
+		}
+	case yaml.SequenceNode:
+		merged.Style = previous.Style
+		merged.Content = make([]*yaml.Node, len(updated.Content))
+		for i, item := range updated.Content {
+			if i < len(previous.Content) {
+				merged.Content[i] = mergeNodes(previous.Content[i], item)
+			} else {
+				merged.Content[i] = item
+			}
+		}
+	case yaml.ScalarNode:
+		if previous.ShortTag() == updated.ShortTag() {
+			merged.Style = previous.Style
+		}
+	}
+	return &merged
+}
+
+// withComments returns a copy of node carrying the comments of previous
+func withComments(node, previous *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.HeadComment = previous.HeadComment
+	copied.LineComment = previous.LineComment
+	copied.FootComment = previous.FootComment
+	return &copied
+}
@@ -0,0 +1,316 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testService struct {
+	Name string            `yaml:"name"`
+	Port int               `yaml:"port,omitempty"`
+	Env  map[string]string `yaml:"env,omitempty"`
+}
+
+type testConfig struct {
+	Title    string            `yaml:"title"`
+	Server   map[string]any    `yaml:"server,omitempty"`
+	Services []testService     `yaml:"services,omitempty"`
+	Owner    map[string]string `yaml:"owner,omitempty"`
+}
+
+const testJSON = `{
+  "services": [
+    {
+      "name": "api",
+      "port": 5000,
+      "env": {
+        "B": "2",
+        "A": "1"
+      }
+    }
+  ],
+  "title": "demo",
+  "server": {
+    "host": "localhost",
+    "tls": {
+      "enabled": true
+    }
+  },
+  "owner": {
+    "name": "dev"
+  }
+}
+`
+
+const testYAML = `# Launcher config
+services:
+  # first service
+  - name: api # the API
+    port: 5000
+    env:
+      B: "2"
+      A: "1"
+owner:
+  name: dev # who to ask
+title: demo
+server:
+  host: localhost
+  tls:
+    enabled: true
+# end of file
+`
+
+const testTOML = `# Launcher config
+title = "demo" # the title
+
+# server settings
+[server]
+host = "localhost"
+tls.enabled = true
+
+[owner]
+name = "dev"
+
+# first service
+[[services]]
+name = "api" # the API
+port = 5000
+env = { B = "2", A = "1" }
+# end of file
+`
+
+// edit removes the owner, changes and adds values and adds a service
+func edit(c *testConfig) {
+	c.Owner = nil
+	c.Services[0].Port = 5001
+	c.Services[0].Env["C"] = "3"
+	c.Server["timeout"] = 30
+	c.Services = append(c.Services, testService{Name: "web"})
+}
+
+func TestMarshalUnchanged(t *testing.T) {
+	for ext, data := range map[string]string{".json": testJSON, ".yaml": testYAML, ".toml": testTOML} {
+		t.Run(ext, func(t *testing.T) {
+			var c testConfig
+			if err := Unmarshal([]byte(data), ext, &c); err != nil {
+				t.Fatal(err)
+			}
+			out, err := Marshal(c, ext, []byte(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != data {
+				t.Errorf("got\n%s\nwant\n%s", out, data)
+			}
+		})
+	}
+}
+
+func TestMarshalMerge(t *testing.T) {
+	tests := []struct {
+		ext  string
+		data string
+		want string
+	}{
+		{
+			ext:  ".json",
+			data: testJSON,
+			want: `{
+  "services": [
+    {
+      "name": "api",
+      "port": 5001,
+      "env": {
+        "B": "2",
+        "A": "1",
+        "C": "3"
+      }
+    },
+    {
+      "name": "web"
+    }
+  ],
+  "title": "demo",
+  "server": {
+    "host": "localhost",
+    "tls": {
+      "enabled": true
+    },
+    "timeout": 30
+  }
+}
+`,
+		},
+		{
+			ext:  ".yaml",
+			data: testYAML,
+			want: `# Launcher config
+services:
+  # first service
+  - name: api # the API
+    port: 5001
+    env:
+      B: "2"
+      A: "1"
+      C: "3"
+  - name: web
+title: demo
+server:
+  host: localhost
+  tls:
+    enabled: true
+  timeout: 30
+# end of file
+`,
+		},
+		{
+			ext:  ".toml",
+			data: testTOML,
+			want: `# Launcher config
+title = "demo" # the title
+
+# server settings
+[server]
+host = "localhost"
+tls.enabled = true
+timeout = 30
+
+# first service
+[[services]]
+name = "api" # the API
+port = 5001
+env = { B = "2", A = "1", C = "3" }
+
+[[services]]
+name = "web"
+# end of file
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			var c testConfig
+			if err := Unmarshal([]byte(tt.data), tt.ext, &c); err != nil {
+				t.Fatal(err)
+			}
+			edit(&c)
+			out, err := Marshal(c, tt.ext, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", out, tt.want)
+			}
+			var reloaded testConfig
+			if err := Unmarshal(out, tt.ext, &reloaded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(reloaded, c) {
+				t.Errorf("reloaded %+v, want %+v", reloaded, c)
+			}
+		})
+	}
+}
+
+func TestMarshalNew(t *testing.T) {
+	c := testConfig{
+		Title:    "demo",
+		Server:   map[string]any{"host": "localhost", "tls": map[string]any{"enabled": true}},
+		Services: []testService{{Name: "api", Env: map[string]string{"A": "1"}}, {Name: "web", Port: 3000}},
+	}
+	want := `title = "demo"
+
+[server]
+host = "localhost"
+
+[server.tls]
+enabled = true
+
+[[services]]
+name = "api"
+
+[services.env]
+A = "1"
+
+[[services]]
+name = "web"
+port = 3000
+`
+	out, err := Marshal(c, ".toml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		want      map[string]any
+		expectErr bool
+	}{
+		{
+			name: "dotted keys",
+			data: "a.b.c = 1\n[t]\nx.y = \"z\"\n",
+			want: map[string]any{"a": map[string]any{"b": map[string]any{"c": 1}}, "t": map[string]any{"x": map[string]any{"y": "z"}}},
+		},
+		{
+			name: "inline tables and arrays",
+			data: "t = { a = 1, b.c = [1, 2] }\nlist = [{ n = \"x\" }]\n",
+			want: map[string]any{"t": map[string]any{"a": 1, "b": map[string]any{"c": []any{1, 2}}}, "list": []any{map[string]any{"n": "x"}}},
+		},
+		{
+			name: "arrays of tables with subtables",
+			data: "[[s]]\nn = 1\n[s.env]\nA = \"1\"\n[[s]]\nn = 2\n",
+			want: map[string]any{"s": []any{map[string]any{"n": 1, "env": map[string]any{"A": "1"}}, map[string]any{"n": 2}}},
+		},
+		{name: "duplicate key", data: "a = 1\na = 2\n", expectErr: true},
+		{name: "value used as a table", data: "a = 1\n[a]\n", expectErr: true},
+		{name: "syntax error", data: "a = \n", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]any
+			err := Unmarshal([]byte(tt.data), ".toml", &got)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalTOMLDottedKeys(t *testing.T) {
+	data := "[server]\n# TLS\ntls.enabled = true # on\n"
+	var c map[string]any
+	if err := Unmarshal([]byte(data), ".toml", &c); err != nil {
+		t.Fatal(err)
+	}
+	c["server"].(map[string]any)["tls"].(map[string]any)["port"] = 443
+	out, err := Marshal(c, ".toml", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[server]\n# TLS\ntls.enabled = true # on\ntls.port = 443\n"; string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	if _, err := Marshal(testConfig{}, ".ini", nil); err == nil {
+		t.Error("expected error for an unsupported format")
+	}
+}
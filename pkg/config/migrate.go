@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config format version this launcher reads and writes
+const CurrentVersion = 2
+
+// migrations[i] upgrades a config document from version i to i+1. They work on
+// the node tree so comments survive, and must never be reordered or removed.
+var migrations = []func(root *yaml.Node) error{
+	wrapFlatServices,    // 0 -> 1
+	explicitServiceType, // 1 -> 2
+}
+
+// migrate upgrades a parsed config document in place and returns the version it had
+func migrate(node *yaml.Node) (int, error) {
+	root := node
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return 0, fmt.Errorf("config must be a mapping")
+	}
+
+	version := 0
+	if _, value := lookupKey(root, "version"); value != nil {
+		v, err := strconv.Atoi(value.Value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid version %q", value.Value)
+		}
+		version = v
+	}
+	if version > CurrentVersion {
+		return version, fmt.Errorf("config version %d is newer than this launcher supports (%d), please update the launcher", version, CurrentVersion)
+	}
+
+	for v := version; v < CurrentVersion; v++ {
+		if err := migrations[v](root); err != nil {
+			return version, fmt.Errorf("migration from version %d failed: %w", v, err)
+		}
+	}
+	setKey(root, "version", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(CurrentVersion)})
+	return version, nil
+}
+
+// wrapFlatServices moves services of the original flat format, a map of service
+// ID to service, into a "Default" group
+func wrapFlatServices(root *yaml.Node) error {
+	if _, groups := lookupKey(root, "groups"); groups != nil || len(root.Content) == 0 {
+		return nil
+	}
+	var services map[string]ServiceConfig
+	if err := root.Decode(&services); err != nil {
+		return err
+	}
+	var migrated yaml.Node
+	if err := migrated.Encode(MigrateFromOldFormat(services)); err != nil {
+		return err
+	}
+	root.Content = migrated.Content
+	return nil
+}
+
+// explicitServiceType records the dotnet default of services without a type
+func explicitServiceType(root *yaml.Node) error {
+	_, groups := lookupKey(root, "groups")
+	if groups == nil {
+		return nil
+	}
+	for i := 1; i < len(groups.Content); i += 2 {
+		_, services := lookupKey(groups.Content[i], "services")
+		if services == nil {
+			continue
+		}
+		for j := 1; j < len(services.Content); j += 2 {
+			service := services.Content[j]
+			if _, serviceType := lookupKey(service, "type"); serviceType == nil || serviceType.Value == "" {
+				setKey(service, "type", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "dotnet"})
+			}
+		}
+	}
+	return nil
+}
+
+// setKey sets a key of a mapping node, appending it when missing
+func setKey(mapping *yaml.Node, name string, value *yaml.Node) {
+	if mapping.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, value)
+}
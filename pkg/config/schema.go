@@ -0,0 +1,87 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// SchemaFileName is the JSON Schema written next to the config file
+const SchemaFileName = "services.schema.json"
+
+// schemaEnums restricts string properties, keyed by "Type.jsonName"
+var schemaEnums = map[string][]string{
+	"ServiceConfig.type":           ServiceTypes,
+	"ServiceConfig.packageManager": PackageManagers,
+}
+
+// Schema returns a JSON Schema describing the config file, generated from the
+// Go types so it cannot drift from what Load accepts
+func Schema() ([]byte, error) {
+	schema := schemaFor(reflect.TypeOf(Config{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "wails-launcher services configuration"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// WriteSchema writes the schema into dir unless it is already up to date
+func WriteSchema(dir string) error {
+	schema, err := Schema()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, SchemaFileName)
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, schema) {
+		return nil
+	}
+	return WriteFileAtomic(path, schema, 0644)
+}
+
+// schemaComment returns the editor directive pointing new YAML and TOML files at the schema
+func schemaComment(ext string) []byte {
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		return []byte("# yaml-language-server: $schema=./" + SchemaFileName + "\n")
+	case ".toml":
+		return []byte("#:schema ./" + SchemaFileName + "\n")
+	}
+	return nil
+}
+
+// schemaFor describes a Go type as a JSON Schema object
+func schemaFor(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaFor(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" || !field.IsExported() {
+				continue
+			}
+			property := schemaFor(field.Type)
+			if enum, ok := schemaEnums[t.Name()+"."+name]; ok {
+				property["enum"] = enum
+			}
+			properties[name] = property
+		}
+		// Unknown keys are typos more often than not
+		return map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+	}
+	return map[string]any{}
+}
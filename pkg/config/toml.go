@@ -0,0 +1,423 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// parseTOML parses TOML into a node tree. Comment lines are attached to the
+// following key or table, trailing comments to the key on the same line.
+func parseTOML(data []byte) (*yaml.Node, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	current := root
+	var pending []string
+
+	parser := unstable.Parser{KeepComments: true}
+	parser.Reset(data)
+	for parser.NextExpression() {
+		expr := parser.Expression()
+		var key *yaml.Node
+
+		switch expr.Kind {
+		case unstable.Comment:
+			pending = append(pending, string(expr.Data))
+			continue
+		case unstable.KeyValue:
+			value, err := tomlValue(expr.Value())
+			if err != nil {
+				return nil, err
+			}
+			key, err = setTOMLKey(current, tomlKey(expr), value)
+			if err != nil {
+				return nil, err
+			}
+		case unstable.Table:
+			parent, err := tomlTable(root, tomlKey(expr), 0)
+			if err != nil {
+				return nil, err
+			}
+			key, current = parent.key, parent.value
+		case unstable.ArrayTable:
+			parts := tomlKey(expr)
+			parent, err := tomlTable(root, parts[:len(parts)-1], 0)
+			if err != nil {
+				return nil, err
+			}
+			k, list := lookupKey(parent.value, parts[len(parts)-1])
+			if list == nil {
+				list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+				k = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: parts[len(parts)-1]}
+				parent.value.Content = append(parent.value.Content, k, list)
+			} else if list.Kind != yaml.SequenceNode {
+				return nil, fmt.Errorf("key %q is defined twice", strings.Join(parts, "."))
+			}
+			current = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			list.Content = append(list.Content, current)
+			key = current
+		}
+
+		if len(pending) > 0 {
+			key.HeadComment = strings.Join(pending, "\n")
+			pending = nil
+		}
+		if next := expr.Next(); next != nil && next.Kind == unstable.Comment {
+			key.LineComment = string(next.Data)
+		}
+	}
+	if err := parser.Error(); err != nil {
+		return nil, err
+	}
+	root.FootComment = strings.Join(pending, "\n")
+	return root, nil
+}
+
+// tomlKey returns the parts of the dotted key of a key/value or table expression
+func tomlKey(expr *unstable.Node) []string {
+	var parts []string
+	it := expr.Key()
+	for it.Next() {
+		parts = append(parts, string(it.Node().Data))
+	}
+	return parts
+}
+
+// tomlValue converts a TOML value into a node
+func tomlValue(value *unstable.Node) (*yaml.Node, error) {
+	switch value.Kind {
+	case unstable.String:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: string(value.Data)}, nil
+	case unstable.Bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: string(value.Data)}, nil
+	case unstable.Integer:
+		n, err := strconv.ParseInt(strings.ReplaceAll(string(value.Data), "_", ""), 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(n, 10)}, nil
+	case unstable.Float:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strings.ReplaceAll(string(value.Data), "_", "")}, nil
+	case unstable.Array:
+		list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+		it := value.Children()
+		for it.Next() {
+			if it.Node().Kind == unstable.Comment {
+				continue
+			}
+			item, err := tomlValue(it.Node())
+			if err != nil {
+				return nil, err
+			}
+			list.Content = append(list.Content, item)
+		}
+		return list, nil
+	case unstable.InlineTable:
+		table := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Style: yaml.FlowStyle}
+		it := value.Children()
+		for it.Next() {
+			if it.Node().Kind != unstable.KeyValue {
+				continue
+			}
+			item, err := tomlValue(it.Node().Value())
+			if err != nil {
+				return nil, err
+			}
+			if _, err := setTOMLKey(table, tomlKey(it.Node()), item); err != nil {
+				return nil, err
+			}
+		}
+		return table, nil
+	default:
+		// Dates and times are kept as their literal text
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: string(value.Data)}, nil
+	}
+}
+
+// dottedStyle marks tables created by dotted keys, like tls.enabled = true, so
+// they are written back the same way. It has no other meaning for mappings.
+const dottedStyle = yaml.LiteralStyle
+
+// keyValue is a key node with its value
+type keyValue struct {
+	key, value *yaml.Node
+}
+
+// tomlTable returns the table at the dotted key, creating missing ones with
+// style. The last element of an array of tables is used when the path runs
+// through one.
+func tomlTable(root *yaml.Node, parts []string, style yaml.Style) (keyValue, error) {
+	current := keyValue{key: root, value: root}
+	for _, part := range parts {
+		k, v := lookupKey(current.value, part)
+		if v == nil {
+			k = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part}
+			v = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Style: style}
+			current.value.Content = append(current.value.Content, k, v)
+		}
+		if v.Kind == yaml.SequenceNode && len(v.Content) > 0 {
+			v = v.Content[len(v.Content)-1]
+		}
+		if v.Kind != yaml.MappingNode {
+			return keyValue{}, fmt.Errorf("key %q is not a table", strings.Join(parts, "."))
+		}
+		current = keyValue{key: k, value: v}
+	}
+	return current, nil
+}
+
+// setTOMLKey sets a dotted key in table and returns the key node
+func setTOMLKey(table *yaml.Node, parts []string, value *yaml.Node) (*yaml.Node, error) {
+	parent, err := tomlTable(table, parts[:len(parts)-1], dottedStyle)
+	if err != nil {
+		return nil, err
+	}
+	name := parts[len(parts)-1]
+	if k, _ := lookupKey(parent.value, name); k != nil {
+		return nil, fmt.Errorf("key %q is defined twice", strings.Join(parts, "."))
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+	parent.value.Content = append(parent.value.Content, key, value)
+	return key, nil
+}
+
+// lookupKey finds a key in a mapping node
+func lookupKey(mapping *yaml.Node, name string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// writeTOML renders a node tree as TOML. Non-empty mappings become tables and
+// block lists of mappings arrays of tables, other lists and flow mappings are
+// written inline.
+func writeTOML(node *yaml.Node) ([]byte, error) {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("TOML documents must be tables")
+	}
+	var buf bytes.Buffer
+	writeTOMLTable(&buf, nil, node)
+	writeTOMLComment(&buf, node.FootComment)
+	return bytes.TrimLeft(buf.Bytes(), "\n"), nil
+}
+
+// writeTOMLTable writes the key/values of a table, then its subtables
+func writeTOMLTable(buf *bytes.Buffer, path []string, table *yaml.Node) {
+	var subtables []int
+	for i := 0; i+1 < len(table.Content); i += 2 {
+		key, value := table.Content[i], table.Content[i+1]
+		if value.ShortTag() == "!!null" {
+			continue
+		}
+		if isTOMLTable(value) || isTOMLArrayTable(value) {
+			subtables = append(subtables, i)
+			continue
+		}
+		writeTOMLKeyValue(buf, "", key, value)
+	}
+
+	for _, i := range subtables {
+		key, value := table.Content[i], table.Content[i+1]
+		subpath := append(append([]string{}, path...), tomlKeyName(key.Value))
+		if value.Kind == yaml.SequenceNode {
+			for j, item := range value.Content {
+				buf.WriteByte('\n')
+				if j == 0 {
+					writeTOMLComment(buf, key.HeadComment)
+				}
+				writeTOMLComment(buf, item.HeadComment)
+				buf.WriteString("[[" + strings.Join(subpath, ".") + "]]")
+				writeTOMLLineComment(buf, item, item)
+				buf.WriteByte('\n')
+				writeTOMLTable(buf, subpath, item)
+			}
+			continue
+		}
+		// Tables holding only other tables need no header of their own
+		if hasTOMLKeyValues(value) || key.HeadComment != "" || key.LineComment != "" {
+			buf.WriteByte('\n')
+			writeTOMLComment(buf, key.HeadComment)
+			buf.WriteString("[" + strings.Join(subpath, ".") + "]")
+			writeTOMLLineComment(buf, key, value)
+			buf.WriteByte('\n')
+		}
+		writeTOMLTable(buf, subpath, value)
+	}
+}
+
+// writeTOMLKeyValue writes key = value, tables created by dotted keys as one
+// line per value with prefix and the key in front
+func writeTOMLKeyValue(buf *bytes.Buffer, prefix string, key, value *yaml.Node) {
+	name := prefix + tomlKeyName(key.Value)
+	if isTOMLDotted(value) {
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			if value.Content[i+1].ShortTag() != "!!null" {
+				writeTOMLKeyValue(buf, name+".", value.Content[i], value.Content[i+1])
+			}
+		}
+		return
+	}
+	writeTOMLComment(buf, key.HeadComment)
+	buf.WriteString(name + " = ")
+	writeTOMLValue(buf, value)
+	writeTOMLLineComment(buf, key, value)
+	buf.WriteByte('\n')
+}
+
+// isTOMLTable reports whether a value is written as a table
+func isTOMLTable(node *yaml.Node) bool {
+	return node.Kind == yaml.MappingNode && len(node.Content) > 0 && node.Style&(yaml.FlowStyle|dottedStyle) == 0
+}
+
+// isTOMLDotted reports whether a value is written as dotted keys
+func isTOMLDotted(node *yaml.Node) bool {
+	return node.Kind == yaml.MappingNode && len(node.Content) > 0 && node.Style&dottedStyle != 0
+}
+
+// isTOMLArrayTable reports whether a value is written as an array of tables
+func isTOMLArrayTable(node *yaml.Node) bool {
+	if node.Kind != yaml.SequenceNode || len(node.Content) == 0 || node.Style&yaml.FlowStyle != 0 {
+		return false
+	}
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode || item.Style&yaml.FlowStyle != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hasTOMLKeyValues reports whether a table has entries written as key = value
+func hasTOMLKeyValues(table *yaml.Node) bool {
+	for i := 1; i < len(table.Content); i += 2 {
+		value := table.Content[i]
+		if value.ShortTag() == "!!null" {
+			continue
+		}
+		if !isTOMLTable(value) && !isTOMLArrayTable(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTOMLValue writes a value inline
+func writeTOMLValue(buf *bytes.Buffer, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		first := true
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i+1].ShortTag() == "!!null" {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			buf.WriteString(" " + tomlKeyName(node.Content[i].Value) + " = ")
+			writeTOMLValue(buf, node.Content[i+1])
+		}
+		if !first {
+			buf.WriteByte(' ')
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			writeTOMLValue(buf, item)
+		}
+		buf.WriteByte(']')
+	case yaml.AliasNode:
+		writeTOMLValue(buf, node.Alias)
+	default:
+		switch node.ShortTag() {
+		case "!!int", "!!float", "!!bool":
+			buf.WriteString(node.Value)
+		default:
+			buf.WriteString(tomlString(node.Value))
+		}
+	}
+}
+
+// writeTOMLComment writes comment lines, adding the marker where missing
+func writeTOMLComment(buf *bytes.Buffer, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") && strings.TrimSpace(line) != "" {
+			line = "# " + line
+		}
+		buf.WriteString(line + "\n")
+	}
+}
+
+// writeTOMLLineComment writes the trailing comment of a key or its value
+func writeTOMLLineComment(buf *bytes.Buffer, key, value *yaml.Node) {
+	comment := key.LineComment
+	if comment == "" {
+		comment = value.LineComment
+	}
+	if comment == "" {
+		return
+	}
+	if !strings.HasPrefix(comment, "#") {
+		comment = "# " + comment
+	}
+	buf.WriteString(" " + comment)
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKeyName quotes a key unless it is a valid bare key
+func tomlKeyName(key string) string {
+	if bareKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString writes a TOML basic string
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,293 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"wails-launcher/pkg/interpolate"
+	"wails-launcher/pkg/launchsettings"
+	"wails-launcher/pkg/workspace"
+)
+
+// ServiceTypes are the supported values of ServiceConfig.Type
+var ServiceTypes = []string{"dotnet", "npm", "compose", "command"}
+
+// PackageManagers are the supported values of ServiceConfig.PackageManager
+var PackageManagers = []string{"npm", "pnpm", "yarn", "bun"}
+
+// FieldError is a problem with a single field of a service or group
+type FieldError struct {
+	Field   string `json:"field"` // JSON name of the field, e.g. "path" or "env.MY_VAR"
+	Message string `json:"message"`
+}
+
+// ValidationError lists the problems found in a service or group
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// add records a problem with a field
+func (e *ValidationError) add(field, format string, args ...any) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+var envKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateService checks a service on its own, without looking at other services
+func ValidateService(svc ServiceConfig) ValidationError {
+	var errs ValidationError
+	if strings.TrimSpace(svc.Name) == "" {
+		errs.add("name", "is required")
+	}
+
+	if svc.Path == "" {
+		errs.add("path", "is required")
+	} else if info, err := os.Stat(svc.Path); os.IsNotExist(err) {
+		errs.add("path", "%s does not exist", svc.Path)
+	} else if err != nil {
+		errs.add("path", "%v", err)
+	} else if !info.IsDir() {
+		errs.add("path", "%s is not a directory", svc.Path)
+	}
+
+	if !slices.Contains(ServiceTypes, svc.Type) {
+		errs.add("type", "unknown type %q, expected one of %s", svc.Type, strings.Join(ServiceTypes, ", "))
+	}
+
+	validateEnv(&errs, svc.Env)
+	validateEnvFiles(&errs, svc.EnvFilePaths())
+
+	if svc.LaunchProfile != "" {
+		if svc.Type != "dotnet" {
+			errs.add("launchProfile", "only dotnet services have launch profiles")
+		} else if settings, err := launchsettings.Load(svc.Path); err != nil {
+			errs.add("launchProfile", "%v", err)
+		} else if profile := settings.Profile(svc.LaunchProfile); profile == nil {
+			errs.add("launchProfile", "%s has no profile %q", launchsettings.FilePath(svc.Path), svc.LaunchProfile)
+		} else if profile.Profile.CommandName != "Project" {
+			errs.add("launchProfile", "profile %q has commandName %q, dotnet run only supports \"Project\"", svc.LaunchProfile, profile.Profile.CommandName)
+		}
+	}
+
+	if svc.PackageManager != "" {
+		if svc.Type != "npm" {
+			errs.add("packageManager", "only npm services have a package manager")
+		} else if !slices.Contains(PackageManagers, svc.PackageManager) {
+			errs.add("packageManager", "unknown package manager %q, expected one of %s", svc.PackageManager, strings.Join(PackageManagers, ", "))
+		}
+	}
+	if svc.Script != "" {
+		if svc.Type != "npm" {
+			errs.add("script", "only npm services run a package.json script")
+		} else if pkg, err := workspace.ReadPackage(svc.Path); err == nil {
+			if _, ok := pkg.Scripts[svc.Script]; !ok {
+				errs.add("script", "%s has no script %q", filepath.Join(svc.Path, "package.json"), svc.Script)
+			}
+		}
+	}
+
+	if svc.Type == "compose" {
+		validateCompose(&errs, svc)
+	} else if svc.ComposeFile != "" || svc.ComposeService != "" {
+		errs.add("composeService", "only compose services run a compose service")
+	}
+	if svc.Type == "command" {
+		if strings.TrimSpace(svc.Command) == "" {
+			errs.add("command", "is required")
+		}
+	} else if svc.Command != "" {
+		errs.add("command", "only command services run a command")
+	}
+
+	for _, port := range svc.Ports {
+		if port < 1 || port > 65535 {
+			errs.add("ports", "%d is not a valid port", port)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, spec := range svc.NamedPorts {
+		switch {
+		case spec.Name == "":
+			errs.add("namedPorts", "port names cannot be empty")
+		case seen[spec.Name]:
+			errs.add("namedPorts", "port %q is declared twice", spec.Name)
+		}
+		seen[spec.Name] = true
+		for _, key := range spec.Env {
+			if !envKeyRegex.MatchString(key) {
+				errs.add("namedPorts", "%q is not a valid environment variable name", key)
+			}
+		}
+	}
+
+	if svc.Proxy != nil {
+		if svc.Proxy.Host == "" && svc.Proxy.PathPrefix == "" {
+			errs.add("proxy", "needs a host or a path prefix")
+		}
+		if svc.Proxy.PathPrefix != "" && !strings.HasPrefix(svc.Proxy.PathPrefix, "/") {
+			errs.add("proxy.pathPrefix", "must start with /")
+		}
+		if strings.ContainsAny(svc.Proxy.Host, "/: ") {
+			errs.add("proxy.host", "must be a plain host name without scheme or port")
+		}
+	}
+	return errs
+}
+
+// ValidateGroup checks a group's own fields
+func ValidateGroup(grp GroupConfig) ValidationError {
+	var errs ValidationError
+	if strings.TrimSpace(grp.Name) == "" {
+		errs.add("name", "is required")
+	}
+	validateEnv(&errs, grp.Env)
+	validateEnvFiles(&errs, grp.EnvFilePaths())
+	return errs
+}
+
+// ValidateEnvironment checks the global variables and the profiles
+func ValidateEnvironment(env *EnvironmentConfig) ValidationError {
+	var errs ValidationError
+	if env == nil {
+		return errs
+	}
+	var globalErrs ValidationError
+	validateEnv(&globalErrs, env.Env)
+	for _, fe := range globalErrs {
+		errs.add("environment."+fe.Field, "%s", fe.Message)
+	}
+	names := make([]string, 0, len(env.Profiles))
+	for name := range env.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			errs.add("environment.profiles", "profile names cannot be empty")
+		}
+		var profileErrs ValidationError
+		validateEnv(&profileErrs, env.Profiles[name])
+		for _, fe := range profileErrs {
+			errs.add("environment.profiles."+name+"."+fe.Field, "%s", fe.Message)
+		}
+	}
+	return errs
+}
+
+// ValidateRedaction checks that the sensitive names and the patterns compile
+func ValidateRedaction(redaction *RedactionConfig) ValidationError {
+	var errs ValidationError
+	if redaction == nil {
+		return errs
+	}
+	for i, name := range redaction.SensitiveEnv {
+		if strings.TrimSpace(name) == "" {
+			errs.add(fmt.Sprintf("redaction.sensitiveEnv.%d", i), "cannot be empty")
+		} else if _, err := path.Match(name, ""); err != nil {
+			errs.add(fmt.Sprintf("redaction.sensitiveEnv.%d", i), "%q is not a valid name pattern", name)
+		}
+	}
+	for i, pattern := range redaction.Patterns {
+		if pattern == "" {
+			errs.add(fmt.Sprintf("redaction.patterns.%d", i), "cannot be empty")
+		} else if _, err := regexp.Compile(pattern); err != nil {
+			errs.add(fmt.Sprintf("redaction.patterns.%d", i), "%v", err)
+		}
+	}
+	return errs
+}
+
+// validateEnv checks that environment variable names are portable and references in values are well formed
+func validateEnv(errs *ValidationError, env ServiceEnv) {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !envKeyRegex.MatchString(key) {
+			errs.add("env."+key, "is not a valid environment variable name")
+		}
+		if err := interpolate.Check(env[key]); err != nil {
+			errs.add("env."+key, "%v", err)
+		}
+	}
+}
+
+// validateEnvFiles checks that existing dotenv files can be parsed, missing ones are allowed
+func validateEnvFiles(errs *ValidationError, paths []string) {
+	for _, path := range paths {
+		if _, _, err := LoadEnvFiles([]string{path}); err != nil {
+			errs.add("envFiles", "%v", err)
+		}
+	}
+}
+
+// Validation holds the problems found in a set of groups
+type Validation struct {
+	Groups   map[string]ValidationError // By group ID
+	Services map[string]ValidationError // By service ID
+}
+
+// Validate checks all groups and services, including what spans services:
+// names must be unique within a group, where dependencies and references to
+// other services look them up, and dependencies must name a service of the
+// same group.
+func Validate(groups map[string]GroupConfig) Validation {
+	result := Validation{
+		Groups:   make(map[string]ValidationError),
+		Services: make(map[string]ValidationError),
+	}
+
+	for groupId, grp := range groups {
+		if errs := ValidateGroup(grp); len(errs) > 0 {
+			result.Groups[groupId] = errs
+		}
+		byName := make(map[string]int) // Services by lower case name
+		for _, svc := range grp.Services {
+			byName[strings.ToLower(strings.TrimSpace(svc.Name))]++
+		}
+		for id, svc := range grp.Services {
+			errs := ValidateService(svc)
+			if count := byName[strings.ToLower(strings.TrimSpace(svc.Name))]; count > 1 && strings.TrimSpace(svc.Name) != "" {
+				errs.add("name", "%q is used by %d services of the group", svc.Name, count)
+			}
+			for _, dep := range svc.DependsOn {
+				switch {
+				case dep == svc.Name:
+					errs.add("dependsOn", "a service cannot depend on itself")
+				case !groupHasService(grp, dep):
+					errs.add("dependsOn", "no service named %q in group %s", dep, grp.Name)
+				}
+			}
+			if len(errs) > 0 {
+				result.Services[id] = errs
+			}
+		}
+	}
+	return result
+}
+
+// groupHasService reports whether a group has a service with the given name,
+// matched exactly like StartGroup does
+func groupHasService(grp GroupConfig, name string) bool {
+	for _, svc := range grp.Services {
+		if svc.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+package devcert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	caCertFile = "ca.pem"
+	caKeyFile  = "ca-key.pem"
+
+	caValidity   = 10 * 365 * 24 * time.Hour
+	leafValidity = 397 * 24 * time.Hour // Longest lifetime browsers accept for leaf certificates
+)
+
+// Authority is a local root CA that issues certificates for development hostnames
+type Authority struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+
+	mu     sync.Mutex
+	hosts  map[string]bool // Names leaves are issued for besides localhost and IP addresses
+	leaves map[string]*tls.Certificate
+}
+
+// DefaultDir returns the directory the launcher keeps its CA in
+func DefaultDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "wails-launcher", "ca"), nil
+}
+
+// LoadOrCreate loads the CA from dir, generating a new one on first use
+func LoadOrCreate(dir string) (*Authority, error) {
+	certPEM, certErr := os.ReadFile(filepath.Join(dir, caCertFile))
+	keyPEM, keyErr := os.ReadFile(filepath.Join(dir, caKeyFile))
+	if os.IsNotExist(certErr) && os.IsNotExist(keyErr) {
+		return create(dir)
+	}
+	if certErr != nil {
+		return nil, certErr
+	}
+	if keyErr != nil {
+		return nil, keyErr
+	}
+	return parse(certPEM, keyPEM)
+}
+
+// create generates a new CA and writes it to dir
+func create(dir string) (*Authority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"wails-launcher development CA"},
+			CommonName:   fmt.Sprintf("wails-launcher CA %s", hostname),
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, caKeyFile), keyPEM, 0600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, caCertFile), certPEM, 0644); err != nil {
+		return nil, err
+	}
+	return parse(certPEM, keyPEM)
+}
+
+// parse builds an Authority from PEM encoded certificate and key
+func parse(certPEM, keyPEM []byte) (*Authority, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, fmt.Errorf("invalid CA certificate")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("invalid CA key")
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &Authority{
+		cert:    cert,
+		key:     key,
+		certPEM: certPEM,
+		leaves:  make(map[string]*tls.Certificate),
+	}, nil
+}
+
+// CertificatePEM returns the CA certificate for importing into trust stores
+func (a *Authority) CertificatePEM() []byte {
+	return a.certPEM
+}
+
+// Export writes the CA certificate to path
+func (a *Authority) Export(path string) error {
+	return os.WriteFile(path, a.certPEM, 0644)
+}
+
+// SetHosts sets the hostnames certificates are issued for, besides localhost
+// and IP addresses, and forgets the certificates of other names
+func (a *Authority) SetHosts(hosts []string) {
+	allowed := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		allowed[normalizeHost(host)] = true
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.hosts = allowed
+	for host := range a.leaves {
+		if !a.allowed(host) {
+			delete(a.leaves, host)
+		}
+	}
+}
+
+// allowed reports whether leaves are issued for host. The caller holds a.mu.
+func (a *Authority) allowed(host string) bool {
+	return host == "localhost" || net.ParseIP(host) != nil || a.hosts[host]
+}
+
+// normalizeHost lower-cases a hostname and removes the trailing dot
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return "localhost"
+	}
+	return host
+}
+
+// Leaf returns a certificate for host signed by the CA, issuing it on first
+// use. Only localhost, IP addresses and the names given to SetHosts get one.
+func (a *Authority) Leaf(host string) (*tls.Certificate, error) {
+	host = normalizeHost(host)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.allowed(host) {
+		return nil, fmt.Errorf("no proxy route for host %q", host)
+	}
+	if leaf, ok := a.leaves[host]; ok && time.Now().Before(leaf.Leaf.NotAfter.Add(-24*time.Hour)) {
+		return leaf, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"wails-launcher development certificate"},
+			CommonName:   host,
+		},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(leafValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	if host == "localhost" {
+		template.IPAddresses = append(template.IPAddresses, net.ParseIP("127.0.0.1"), net.ParseIP("::1"))
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		return nil, err
+	}
+	leafCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	leaf := &tls.Certificate{
+		Certificate: [][]byte{der, a.cert.Raw},
+		PrivateKey:  key,
+		Leaf:        leafCert,
+	}
+	a.leaves[host] = leaf
+	return leaf, nil
+}
+
+// TLSConfig returns a server configuration that issues certificates per SNI
+// hostname, handshakes for other names fail
+func (a *Authority) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			host := hello.ServerName
+			if host == "" || net.ParseIP(host) != nil {
+				// Clients connecting by IP send no SNI, use the local address
+				// instead, so only addresses of this machine get a certificate
+				if addr, ok := hello.Conn.LocalAddr().(*net.TCPAddr); ok {
+					host = addr.IP.String()
+				}
+			}
+			return a.Leaf(host)
+		},
+	}
+}
+
+// randomSerial returns a random 128 bit certificate serial number
+func randomSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
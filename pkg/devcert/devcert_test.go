@@ -0,0 +1,69 @@
+package devcert
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLeafHosts(t *testing.T) {
+	ca, err := LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca.SetHosts([]string{"API.localhost"})
+
+	tests := []struct {
+		host      string
+		dnsNames  []string
+		expectErr bool
+	}{
+		{host: "api.localhost.", dnsNames: []string{"api.localhost"}},
+		{host: "localhost", dnsNames: []string{"localhost"}},
+		{host: ""}, // localhost
+		{host: "127.0.0.1"},
+		{host: "::1"},
+		{host: "web.localhost", expectErr: true},
+		{host: "example.com", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		leaf, err := ca.Leaf(tt.host)
+		if tt.expectErr {
+			if err == nil {
+				t.Errorf("Leaf(%q): expected error", tt.host)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Leaf(%q): %v", tt.host, err)
+			continue
+		}
+		if tt.dnsNames != nil && !reflect.DeepEqual(leaf.Leaf.DNSNames, tt.dnsNames) {
+			t.Errorf("Leaf(%q): got names %v, want %v", tt.host, leaf.Leaf.DNSNames, tt.dnsNames)
+		}
+	}
+}
+
+func TestSetHostsForgetsLeaves(t *testing.T) {
+	ca, err := LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca.SetHosts([]string{"api.localhost", "web.localhost"})
+	for _, host := range []string{"api.localhost", "web.localhost", "localhost"} {
+		if _, err := ca.Leaf(host); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ca.SetHosts([]string{"api.localhost"})
+	if _, cached := ca.leaves["web.localhost"]; cached {
+		t.Error("web.localhost is still cached")
+	}
+	if len(ca.leaves) != 2 {
+		t.Errorf("got %d cached leaves, want 2", len(ca.leaves))
+	}
+	if _, err := ca.Leaf("web.localhost"); err == nil {
+		t.Error("expected error for a removed host")
+	}
+}
@@ -0,0 +1,239 @@
+package dotenv
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Parse reads dotenv content. Supported are comments, an optional "export"
+// prefix, unquoted values ended by a " #" comment (so "KEY= # note" is empty
+// while "KEY=#fff" is not), single quoted values taken literally and double
+// quoted values with escapes. Quoted values may span several lines.
+// Variables are not expanded.
+func Parse(data []byte) (map[string]string, error) {
+	env := make(map[string]string)
+	p := parser{src: strings.ReplaceAll(string(data), "\r\n", "\n"), line: 1}
+	for {
+		p.skipBlank()
+		if p.done() {
+			return env, nil
+		}
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+		line := p.line
+		key, value, err := p.assignment()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		env[key] = value
+	}
+}
+
+// ReadFile parses a dotenv file
+func ReadFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	env, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return env, nil
+}
+
+// Format writes env as dotenv content sorted by name. Values are double
+// quoted when they would not read back as they are.
+func Format(env map[string]string) []byte {
+	var buf bytes.Buffer
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		buf.WriteString(key + "=" + quote(env[key]) + "\n")
+	}
+	return buf.Bytes()
+}
+
+// plainValue matches values written without quotes
+var plainValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+
+// quote double quotes a value unless it is plain
+func quote(value string) string {
+	if plainValue.MatchString(value) {
+		return value
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(value) + `"`
+}
+
+// parser walks the content of a dotenv file
+type parser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.src) }
+
+func (p *parser) peek() byte { return p.src[p.pos] }
+
+// next consumes one byte, keeping track of the line number
+func (p *parser) next() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+// skipBlank skips whitespace including line breaks
+func (p *parser) skipBlank() {
+	for !p.done() && strings.IndexByte(" \t\n", p.peek()) >= 0 {
+		p.next()
+	}
+}
+
+// skipSpaces skips whitespace within the line
+func (p *parser) skipSpaces() {
+	for !p.done() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+// skipLine skips to the start of the next line
+func (p *parser) skipLine() {
+	for !p.done() && p.next() != '\n' {
+	}
+}
+
+// assignment parses KEY=value, optionally prefixed by "export"
+func (p *parser) assignment() (string, string, error) {
+	key := p.word()
+	if key == "export" {
+		p.skipSpaces()
+		if !p.done() && p.peek() != '=' {
+			key = p.word()
+		}
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("expected a variable name")
+	}
+	p.skipSpaces()
+	if p.done() || p.peek() != '=' {
+		return "", "", fmt.Errorf("expected = after %s", key)
+	}
+	p.next()
+	afterEquals := p.pos
+	p.skipSpaces()
+
+	var value string
+	var err error
+	switch {
+	case p.done():
+	case p.peek() == '\'':
+		value, err = p.singleQuoted()
+	case p.peek() == '"':
+		value, err = p.doubleQuoted()
+	case p.peek() == '#' && p.pos > afterEquals:
+		// Only a comment follows "KEY= "
+		p.skipLine()
+		return key, "", nil
+	default:
+		return key, p.unquoted(), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	// Only a comment may follow the closing quote
+	p.skipSpaces()
+	if !p.done() && p.peek() != '\n' && p.peek() != '#' {
+		return "", "", fmt.Errorf("unexpected text after the value of %s", key)
+	}
+	p.skipLine()
+	return key, value, nil
+}
+
+// word reads a variable name
+func (p *parser) word() string {
+	start := p.pos
+	for !p.done() {
+		c := p.peek()
+		if c == '_' || c == '.' || c == '-' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			p.next()
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+// unquoted reads the rest of the line, dropping a comment started by " #"
+func (p *parser) unquoted() string {
+	start := p.pos
+	for !p.done() && p.peek() != '\n' {
+		p.next()
+	}
+	value := p.src[start:p.pos]
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	} else if i := strings.Index(value, "\t#"); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
+
+// singleQuoted reads a literal value up to the closing quote
+func (p *parser) singleQuoted() (string, error) {
+	p.next()
+	start := p.pos
+	for !p.done() {
+		if p.peek() == '\'' {
+			value := p.src[start:p.pos]
+			p.next()
+			return value, nil
+		}
+		p.next()
+	}
+	return "", fmt.Errorf("unterminated quote")
+}
+
+// doubleQuoted reads a value up to the closing quote, resolving escapes
+func (p *parser) doubleQuoted() (string, error) {
+	p.next()
+	var b strings.Builder
+	for !p.done() {
+		c := p.next()
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.done() {
+				break
+			}
+			switch e := p.next(); e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$', '\'':
+				b.WriteByte(e)
+			case '\n':
+				// Line continuation
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated quote")
+}
@@ -0,0 +1,125 @@
+package dotenv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		want      map[string]string
+		expectErr bool
+	}{
+		{
+			name: "plain values",
+			data: "A=1\nB = two words \n\n  C=3\n",
+			want: map[string]string{"A": "1", "B": "two words", "C": "3"},
+		},
+		{
+			name: "comments",
+			data: "# comment\nA=1 # trailing\nB=x\t# tab\n  # indented\nC=url#fragment\n",
+			want: map[string]string{"A": "1", "B": "x", "C": "url#fragment"},
+		},
+		{
+			name: "comment right after the equals sign",
+			data: "A= # comment\nB=\t# comment\nC=#fff\n",
+			want: map[string]string{"A": "", "B": "", "C": "#fff"},
+		},
+		{
+			name: "empty values",
+			data: "A=\nB=\"\"\nC=''",
+			want: map[string]string{"A": "", "B": "", "C": ""},
+		},
+		{
+			name: "export prefix",
+			data: "export A=1\nexport=2\n",
+			want: map[string]string{"A": "1", "export": "2"},
+		},
+		{
+			name: "single quotes are literal",
+			data: `A='${B} \n # not a comment' # comment` + "\n",
+			want: map[string]string{"A": `${B} \n # not a comment`},
+		},
+		{
+			name: "double quote escapes",
+			data: `A="line\nnext\t\"quoted\" \\ \$HOME \x"` + "\n",
+			want: map[string]string{"A": "line\nnext\t\"quoted\" \\ $HOME \\x"},
+		},
+		{
+			name: "multi-line values",
+			data: "A=\"first\nsecond\"\nB='x\ny'\nC=\"joined \\\nline\"\n",
+			want: map[string]string{"A": "first\nsecond", "B": "x\ny", "C": "joined line"},
+		},
+		{
+			name: "windows line endings",
+			data: "A=1\r\nB=\"2\"\r\n",
+			want: map[string]string{"A": "1", "B": "2"},
+		},
+		{
+			name: "later values win",
+			data: "A=1\nA=2\n",
+			want: map[string]string{"A": "2"},
+		},
+		{
+			name: "dotted and dashed names",
+			data: "app.name=x\nlog-level=debug\n",
+			want: map[string]string{"app.name": "x", "log-level": "debug"},
+		},
+		{name: "missing equals sign", data: "A 1\n", expectErr: true},
+		{name: "missing name", data: "=1\n", expectErr: true},
+		{name: "unterminated double quote", data: "A=\"open\nB=1\n", expectErr: true},
+		{name: "unterminated single quote", data: "A='open", expectErr: true},
+		{name: "text after the closing quote", data: "A=\"x\" y\n", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := Parse([]byte(tt.data))
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", env)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(env, tt.want) {
+				t.Errorf("got %q, want %q", env, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrorLine(t *testing.T) {
+	_, err := Parse([]byte("A=1\nB=\"two\nlines\"\nC\n"))
+	if err == nil || err.Error() != "line 4: expected = after C" {
+		t.Errorf("got %v, want the error on line 4", err)
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	env := map[string]string{
+		"PLAIN":   "http://localhost:5000/api",
+		"EMPTY":   "",
+		"SPACES":  "two words",
+		"COMMENT": "a #b",
+		"QUOTES":  `say "hi" it's`,
+		"DOLLAR":  "$HOME\\bin",
+		"LINES":   "first\nsecond\tend",
+	}
+	data := Format(env)
+	if want := "COMMENT=\"a #b\"\nDOLLAR=\"\\$HOME\\\\bin\"\nEMPTY=\nLINES=\"first\\nsecond\\tend\"\n" +
+		"PLAIN=http://localhost:5000/api\nQUOTES=\"say \\\"hi\\\" it's\"\nSPACES=\"two words\"\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, env) {
+		t.Errorf("got %q, want %q", parsed, env)
+	}
+}
@@ -26,6 +26,7 @@ func FindExecutable(name string) (string, error) {
 			homeDir+"/.nvm/versions/node/v22.19.0/bin/"+name,
 			homeDir+"/.nvm/versions/node/v23.5.0/bin/"+name,
 			homeDir+"/.local/bin/"+name,
+			homeDir+"/.bun/bin/"+name,
 		)
 
 		// Try to find any version in .nvm/versions/node/
@@ -1,20 +1,28 @@
 package group
 
 import (
-	"bufio"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
+	"wails-launcher/pkg/compose"
 	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/launchsettings"
+	"wails-launcher/pkg/procfile"
 	"wails-launcher/pkg/service"
+	"wails-launcher/pkg/solution"
+	"wails-launcher/pkg/workspace"
 )
 
 // Manager handles group operations
 type Manager struct {
-	groups map[string]config.GroupConfig
+	groups      map[string]config.GroupConfig
+	environment *config.EnvironmentConfig
 }
 
 // NewManager creates a new group manager
@@ -25,19 +33,22 @@ func NewManager(groups map[string]config.GroupConfig) *Manager {
 	return &Manager{groups: groups}
 }
 
+// SetEnvironment sets the global variables and profiles the groups inherit from
+func (m *Manager) SetEnvironment(environment *config.EnvironmentConfig) {
+	m.environment = environment
+}
+
 // GetGroups returns all groups
 func (m *Manager) GetGroups() map[string]config.GroupConfig {
 	// Return a copy
 	result := make(map[string]config.GroupConfig)
 	for id, group := range m.groups {
-		groupCopy := config.GroupConfig{
-			Name:     group.Name,
-			Env:      make(config.ServiceEnv),
-			Services: make(map[string]config.ServiceConfig),
-		}
+		groupCopy := group
+		groupCopy.Env = make(config.ServiceEnv)
 		for k, v := range group.Env {
 			groupCopy.Env[k] = v
 		}
+		groupCopy.Services = make(map[string]config.ServiceConfig)
 		for sid, sconfig := range group.Services {
 			groupCopy.Services[sid] = sconfig
 		}
@@ -47,22 +58,24 @@ func (m *Manager) GetGroups() map[string]config.GroupConfig {
 }
 
 // AddGroup adds a new group
-func (m *Manager) AddGroup(name string, env config.ServiceEnv) string {
+func (m *Manager) AddGroup(name string, env config.ServiceEnv, envFiles []string) string {
 	groupId := service.GenerateID()
 	group := config.GroupConfig{
 		Name:     name,
 		Env:      env,
 		Services: make(map[string]config.ServiceConfig),
+		EnvFiles: envFiles,
 	}
 	m.groups[groupId] = group
 	return groupId
 }
 
 // UpdateGroup updates a group
-func (m *Manager) UpdateGroup(id string, name string, env config.ServiceEnv) {
+func (m *Manager) UpdateGroup(id string, name string, env config.ServiceEnv, envFiles []string) {
 	if group, exists := m.groups[id]; exists {
 		group.Name = name
 		group.Env = env
+		group.EnvFiles = envFiles
 		m.groups[id] = group
 	}
 }
@@ -100,67 +113,230 @@ func (m *Manager) DeleteServiceFromGroup(groupId string, serviceId string) bool
 	return false
 }
 
-// ImportSLN imports projects from a .sln file and creates a group
-func (m *Manager) ImportSLN(slnPath string) error {
-	file, err := os.Open(slnPath)
+// ImportSLN imports projects of a .sln, .slnx or .slnf file into a new
+// group. Without a selection of project file paths, the projects Detect
+// considers runnable are imported. Solution folders become the services' tags.
+// Returns the ID of the new group.
+func (m *Manager) ImportSLN(slnPath string, projectPaths []string) (string, error) {
+	slnPath, err := filepath.Abs(slnPath)
 	if err != nil {
-		return err
+		return "", err
+	}
+	sln, err := solution.Load(slnPath)
+	if err != nil {
+		return "", err
 	}
-	defer file.Close()
-
-	groupName := strings.TrimSuffix(filepath.Base(slnPath), filepath.Ext(slnPath))
 
 	group := config.GroupConfig{
-		Name:     groupName,
+		Name:     sln.Name,
 		Env:      make(config.ServiceEnv),
 		Services: make(map[string]config.ServiceConfig),
+		Solution: slnPath,
 	}
+	for _, project := range sln.Projects {
+		if len(projectPaths) == 0 {
+			if !solution.Detect(project.Path).Runnable {
+				continue
+			}
+		} else if !slices.Contains(projectPaths, project.Path) {
+			continue
+		}
+		group.Services[service.GenerateID()] = projectService(project)
+	}
+	if len(group.Services) == 0 {
+		return "", fmt.Errorf("no projects to import from %s", filepath.Base(slnPath))
+	}
+
+	groupId := service.GenerateID()
+	m.groups[groupId] = group
+	return groupId, nil
+}
 
-	scanner := bufio.NewScanner(file)
-	projectRegex := regexp.MustCompile(`Project\("\{[^}]+\}"\) = "([^"]+)", "([^"]+\.csproj)", "\{[^}]+\}"`)
+// projectService returns the config of a service running a solution project
+func projectService(project solution.Project) config.ServiceConfig {
+	serviceConfig := config.ServiceConfig{
+		Name:          project.Name,
+		Path:          filepath.Dir(project.Path), // Directory containing the project file
+		Env:           make(config.ServiceEnv),
+		Type:          "dotnet",
+		LaunchProfile: defaultLaunchProfile(filepath.Dir(project.Path)),
+	}
+	if project.Folder != "" {
+		serviceConfig.Tags = []string{project.Folder}
+	}
+	return serviceConfig
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		matches := projectRegex.FindStringSubmatch(line)
-		if len(matches) == 3 {
-			projectName := matches[1]
-			projectPath := matches[2]
+// SolutionDiff compares a group with the solution it was imported from
+type SolutionDiff struct {
+	Solution  string
+	Added     []solution.Project // Projects of the solution without a service
+	Removed   []string           // IDs of dotnet services whose project is no longer in the solution
+	Unchanged []string           // IDs of services whose project is still in the solution
+}
 
-			// Convert Windows backslashes to forward slashes
-			projectPath = strings.ReplaceAll(projectPath, "\\", "/")
+// DiffSolution compares a group with its solution. Services are matched to
+// projects by directory; services of other types are not part of the solution.
+func (m *Manager) DiffSolution(groupId string) (SolutionDiff, error) {
+	group, exists := m.groups[groupId]
+	if !exists {
+		return SolutionDiff{}, fmt.Errorf("group not found: %s", groupId)
+	}
+	if group.Solution == "" {
+		return SolutionDiff{}, fmt.Errorf("group %s was not imported from a solution", group.Name)
+	}
+	sln, err := solution.Load(group.Solution)
+	if err != nil {
+		return SolutionDiff{}, err
+	}
 
-			// Convert relative path to absolute
-			if !filepath.IsAbs(projectPath) {
-				projectPath = filepath.Join(filepath.Dir(slnPath), projectPath)
-			}
+	diff := SolutionDiff{Solution: group.Solution}
+	projectDirs := make(map[string]bool)
+	for _, project := range sln.Projects {
+		projectDirs[filepath.Dir(project.Path)] = true
+	}
+	serviceDirs := make(map[string]bool)
+	for serviceId, serviceConfig := range group.Services {
+		if serviceConfig.Type != "dotnet" {
+			continue
+		}
+		dir := filepath.Clean(serviceConfig.Path)
+		serviceDirs[dir] = true
+		if projectDirs[dir] {
+			diff.Unchanged = append(diff.Unchanged, serviceId)
+		} else {
+			diff.Removed = append(diff.Removed, serviceId)
+		}
+	}
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Unchanged)
+	for _, project := range sln.Projects {
+		if !serviceDirs[filepath.Dir(project.Path)] {
+			diff.Added = append(diff.Added, project)
+		}
+	}
+	return diff, nil
+}
 
+// SyncSolution adds services for the given new projects of the group's
+// solution and removes the given services whose project is gone. Existing
+// services keep their IDs and settings. Returns the IDs of the added services.
+func (m *Manager) SyncSolution(groupId string, addPaths []string, removeIds []string) ([]string, error) {
+	diff, err := m.DiffSolution(groupId)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range addPaths {
+		if !slices.ContainsFunc(diff.Added, func(p solution.Project) bool { return p.Path == path }) {
+			return nil, fmt.Errorf("%s is not a new project of %s", path, filepath.Base(diff.Solution))
+		}
+	}
+	for _, serviceId := range removeIds {
+		if !slices.Contains(diff.Removed, serviceId) {
+			return nil, fmt.Errorf("service %s is still part of %s", serviceId, filepath.Base(diff.Solution))
+		}
+	}
+
+	group := m.groups[groupId]
+	var added []string
+	for _, project := range diff.Added {
+		if slices.Contains(addPaths, project.Path) {
 			serviceId := service.GenerateID()
-			serviceConfig := config.ServiceConfig{
-				Name: projectName,
-				Path: filepath.Dir(projectPath), // Directory containing the .csproj
-				Env:  make(config.ServiceEnv),
-				Type: "dotnet",
-			}
+			group.Services[serviceId] = projectService(project)
+			added = append(added, serviceId)
+		}
+	}
+	for _, serviceId := range removeIds {
+		delete(group.Services, serviceId)
+	}
+	m.groups[groupId] = group
+	return added, nil
+}
 
-			// Only add projects that have a Program.cs file
-			programPath := filepath.Join(serviceConfig.Path, "Program.cs")
-			if _, err := os.Stat(programPath); err == nil {
-				group.Services[serviceId] = serviceConfig
-			}
+// ImportCompose creates a group with a service per service of a compose
+// file. Published ports are checked for conflicts and depends_on becomes the
+// start order. Returns the ID of the new group.
+func (m *Manager) ImportCompose(path string) (string, error) {
+	project, err := compose.Load(path)
+	if err != nil {
+		return "", err
+	}
+	if len(project.Services) == 0 {
+		return "", fmt.Errorf("no services in %s", project.File)
+	}
+
+	group := config.GroupConfig{
+		Name:     project.Name,
+		Env:      make(config.ServiceEnv),
+		Services: make(map[string]config.ServiceConfig),
+	}
+	for _, svc := range project.Services {
+		group.Services[service.GenerateID()] = config.ServiceConfig{
+			Name:           svc.Name,
+			Path:           filepath.Dir(project.File),
+			Env:            make(config.ServiceEnv),
+			Type:           "compose",
+			Ports:          svc.Ports,
+			DependsOn:      svc.DependsOn,
+			ComposeFile:    filepath.Base(project.File),
+			ComposeService: svc.Name,
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return err
+	groupId := service.GenerateID()
+	m.groups[groupId] = group
+	return groupId, nil
+}
+
+// ImportProcfile creates a group with a command service per process type of
+// a Procfile. Like foreman, the services load the .env next to it and get a
+// PORT of their own, 100 apart from the .env PORT or 5000, which is checked
+// for conflicts for the processes that listen on it.
+// Returns the ID of the new group.
+func (m *Manager) ImportProcfile(path string) (string, error) {
+	file, err := procfile.Load(path)
+	if err != nil {
+		return "", err
+	}
+	if len(file.Entries) == 0 {
+		return "", fmt.Errorf("no processes in %s", file.Path)
+	}
+
+	dir := file.Dir()
+	var envFiles []string
+	if _, err := os.Stat(filepath.Join(dir, procfile.EnvFileName)); err == nil {
+		envFiles = []string{procfile.EnvFileName}
+	}
+	group := config.GroupConfig{
+		Name:     filepath.Base(dir),
+		Env:      make(config.ServiceEnv),
+		Services: make(map[string]config.ServiceConfig),
+	}
+	basePort := procfile.BasePort(dir)
+	for i, entry := range file.Entries {
+		port := basePort + i*procfile.PortStep
+		svc := config.ServiceConfig{
+			Name:     entry.Name,
+			Path:     dir,
+			Env:      config.ServiceEnv{"PORT": strconv.Itoa(port)},
+			Type:     "command",
+			EnvFiles: envFiles,
+			Command:  entry.Command,
+		}
+		if entry.Listens() {
+			svc.Ports = []int{port}
+		}
+		group.Services[service.GenerateID()] = svc
 	}
 
 	groupId := service.GenerateID()
 	m.groups[groupId] = group
-	return nil
+	return groupId, nil
 }
 
-// ImportProject imports a single project into a group
-func (m *Manager) ImportProject(groupId string, path string, projectType string) (string, error) {
+// ImportProject imports a single project into a group. An npm project runs
+// the given script, or the dev script package.json has when it is empty.
+func (m *Manager) ImportProject(groupId string, path string, projectType string, script string) (string, error) {
 	dir := filepath.Dir(path)
 	name := filepath.Base(dir) // Default to folder name
 
@@ -186,12 +362,85 @@ func (m *Manager) ImportProject(groupId string, path string, projectType string)
 		Env:  make(config.ServiceEnv),
 		Type: projectType,
 	}
+	if projectType == "dotnet" {
+		serviceConfig.LaunchProfile = defaultLaunchProfile(dir)
+	}
+	if projectType == "npm" {
+		serviceConfig.PackageManager = workspace.DetectPackageManager(dir)
+		serviceConfig.Script = script
+		if pkg, err := workspace.ReadPackage(dir); err == nil && script == "" {
+			serviceConfig.Script = pkg.Script
+		}
+	}
 
 	return m.AddServiceToGroup(groupId, serviceConfig), nil
 }
 
-// EnrichedServiceConfig includes the service config and its inherited environment
+// ImportWorkspace creates a group with a service per package of a JS
+// workspace. scripts selects the packages by directory and the script each
+// runs, an empty script picks the dev script. Without a selection, the
+// packages with a dev script are imported. Parent folders like "apps" become
+// the services' tags. Returns the ID of the new group.
+func (m *Manager) ImportWorkspace(path string, scripts map[string]string) (string, error) {
+	ws, err := workspace.Load(path)
+	if err != nil {
+		return "", err
+	}
+
+	group := config.GroupConfig{
+		Name:     ws.Name,
+		Env:      make(config.ServiceEnv),
+		Services: make(map[string]config.ServiceConfig),
+	}
+	for _, pkg := range ws.Packages {
+		script := pkg.Script
+		if len(scripts) == 0 {
+			if !pkg.App() {
+				continue
+			}
+		} else if selected, ok := scripts[pkg.Dir]; !ok {
+			continue
+		} else if selected != "" {
+			script = selected
+		}
+		serviceConfig := config.ServiceConfig{
+			Name:           pkg.Name,
+			Path:           pkg.Dir,
+			Env:            make(config.ServiceEnv),
+			Type:           "npm",
+			PackageManager: ws.PackageManager,
+			Script:         script,
+		}
+		if pkg.Folder != "" {
+			serviceConfig.Tags = []string{pkg.Folder}
+		}
+		group.Services[service.GenerateID()] = serviceConfig
+	}
+	if len(group.Services) == 0 {
+		return "", fmt.Errorf("no packages to import from %s", ws.Root)
+	}
+
+	groupId := service.GenerateID()
+	m.groups[groupId] = group
+	return groupId, nil
+}
+
+// defaultLaunchProfile returns the launch profile dotnet run picks for a
+// project directory, so imported services keep running the way they did
+func defaultLaunchProfile(dir string) string {
+	settings, err := launchsettings.Load(dir)
+	if err != nil {
+		return ""
+	}
+	if profile := settings.DefaultProfile(); profile != nil {
+		return profile.Name
+	}
+	return ""
+}
+
+// EnrichedServiceConfig includes the service config, its group and its inherited environment
 type EnrichedServiceConfig struct {
+	GroupID      string
 	Config       config.ServiceConfig
 	InheritedEnv config.ServiceEnv
 }
@@ -199,17 +448,48 @@ type EnrichedServiceConfig struct {
 // GetGroupServices returns all services in all groups with their inherited environments
 func (m *Manager) GetGroupServices() map[string]EnrichedServiceConfig {
 	result := make(map[string]EnrichedServiceConfig)
-	for _, group := range m.groups {
+	for groupId, group := range m.groups {
+		layers, _ := m.EnvLayers(groupId)
+		inherited := config.MergeLayers(layers)
 		for serviceId, serviceConfig := range group.Services {
 			result[serviceId] = EnrichedServiceConfig{
+				GroupID:      groupId,
 				Config:       serviceConfig,
-				InheritedEnv: group.Env,
+				InheritedEnv: inherited,
 			}
 		}
 	}
 	return result
 }
 
+// EnvLayers returns the layers of the environment a group passes to its
+// services, later ones win: the global variables, the group's env files, the
+// group env and the group's active profile. Files that cannot be read are
+// left out and the first error is returned.
+func (m *Manager) EnvLayers(groupId string) ([]config.EnvLayer, error) {
+	group := m.groups[groupId]
+	var layers []config.EnvLayer
+	var firstErr error
+	if m.environment != nil {
+		layers = append(layers, config.EnvLayer{Source: "global", Env: m.environment.Env})
+	}
+	for i, path := range group.EnvFilePaths() {
+		env, _, err := config.LoadEnvFiles([]string{path})
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		layers = append(layers, config.EnvLayer{Source: "group " + group.EnvFiles[i], Env: env})
+	}
+	layers = append(layers, config.EnvLayer{Source: "group", Env: group.Env})
+	if name, env, ok := m.environment.Profile(groupId); ok {
+		layers = append(layers, config.EnvLayer{Source: "profile " + name, Env: env})
+	}
+	return layers, firstErr
+}
+
 // FindGroupByService finds the group containing a service
 func (m *Manager) FindGroupByService(serviceId string) (string, bool) {
 	for groupId, group := range m.groups {
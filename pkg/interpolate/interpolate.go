@@ -0,0 +1,272 @@
+package interpolate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Scope answers what an environment does not define itself
+type Scope struct {
+	// Lookup finds variables missing from the environment, e.g. os.LookupEnv
+	Lookup func(name string) (string, bool)
+	// Resolve returns the value of a reference that is not a variable name,
+	// such as "service:api.url". Its result is expanded in turn.
+	Resolve func(ref string) (string, error)
+}
+
+// Error is a reference that could not be expanded in the value of Key
+type Error struct {
+	Key     string
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Key + ": " + e.Message
+}
+
+var nameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Expand replaces references in all values of env, in place:
+//
+//	${VAR}          another variable of env, or one found by scope.Lookup
+//	${VAR:-default} the default when VAR is unset or empty
+//	${other}        anything else is passed to scope.Resolve
+//
+// Values may refer to each other in any order. "$${" yields a literal "${".
+// References that cannot be expanded, including cycles, are left in place
+// and reported, each problem once.
+func Expand(env map[string]string, scope Scope) []Error {
+	e := &expander{
+		raw:   make(map[string]string, len(env)),
+		env:   env,
+		state: make(map[string]int),
+		scope: scope,
+	}
+	keys := make([]string, 0, len(env))
+	for k, v := range env {
+		e.raw[k] = v
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e.variable(k)
+	}
+	return e.errs
+}
+
+// Check reports syntax errors in a value without resolving anything
+func Check(value string) error {
+	_, err := parse(value)
+	return err
+}
+
+// expansion states of a reference
+const (
+	pending = iota
+	expanding
+	expanded
+)
+
+type expander struct {
+	raw   map[string]string
+	env   map[string]string
+	state map[string]int // By variable name or "${ref}"
+	stack []string       // References being expanded, innermost last
+	scope Scope
+	errs  []Error
+}
+
+// fail records a problem with the value currently being expanded
+func (e *expander) fail(format string, args ...any) {
+	key := ""
+	for i := len(e.stack) - 1; i >= 0; i-- {
+		if _, ok := e.raw[e.stack[i]]; ok {
+			key = e.stack[i]
+			break
+		}
+	}
+	e.errs = append(e.errs, Error{Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+// enter marks a reference as being expanded, reporting a cycle if it already is
+func (e *expander) enter(name string) bool {
+	if e.state[name] == expanding {
+		start := 0
+		for i, n := range e.stack {
+			if n == name {
+				start = i
+			}
+		}
+		cycle := append(append([]string{}, e.stack[start:]...), name)
+		e.fail("reference cycle %s", strings.Join(cycle, " -> "))
+		return false
+	}
+	e.state[name] = expanding
+	e.stack = append(e.stack, name)
+	return true
+}
+
+func (e *expander) leave(name string) {
+	e.state[name] = expanded
+	e.stack = e.stack[:len(e.stack)-1]
+}
+
+// variable returns the expanded value of a variable of env
+func (e *expander) variable(name string) (string, bool) {
+	switch e.state[name] {
+	case expanded:
+		return e.env[name], true
+	case expanding:
+		e.enter(name)
+		return "", false
+	}
+	e.enter(name)
+	e.env[name] = e.expand(e.raw[name])
+	e.leave(name)
+	return e.env[name], true
+}
+
+// expand replaces the references in a value
+func (e *expander) expand(value string) string {
+	parts, err := parse(value)
+	if err != nil {
+		e.fail("%v", err)
+		return value
+	}
+	var b strings.Builder
+	for _, p := range parts {
+		if p.ref == nil {
+			b.WriteString(p.text)
+			continue
+		}
+		resolved, ok := e.reference(p.ref)
+		if !ok {
+			b.WriteString(p.text)
+			continue
+		}
+		b.WriteString(resolved)
+	}
+	return b.String()
+}
+
+// reference resolves a single ${...}
+func (e *expander) reference(r *ref) (string, bool) {
+	if nameRegex.MatchString(r.name) {
+		if _, ok := e.raw[r.name]; ok {
+			value, ok := e.variable(r.name)
+			if !ok {
+				return "", false
+			}
+			if value != "" || !r.hasDefault {
+				return value, true
+			}
+		} else if e.scope.Lookup != nil {
+			if value, ok := e.scope.Lookup(r.name); ok && (value != "" || !r.hasDefault) {
+				return value, true
+			}
+		}
+		if r.hasDefault {
+			return e.expand(r.fallback), true
+		}
+		e.fail("${%s} is not defined", r.name)
+		return "", false
+	}
+
+	key := "${" + r.name + "}"
+	if !e.enter(key) {
+		return "", false
+	}
+	defer func() { e.state[key] = pending; e.stack = e.stack[:len(e.stack)-1] }()
+
+	var value string
+	var err error
+	if e.scope.Resolve == nil {
+		err = fmt.Errorf("unknown reference")
+	} else {
+		value, err = e.scope.Resolve(r.name)
+	}
+	if err != nil {
+		if r.hasDefault {
+			return e.expand(r.fallback), true
+		}
+		e.fail("cannot resolve %s: %v", key, err)
+		return "", false
+	}
+	return e.expand(value), true
+}
+
+// part is literal text or a reference, text holds the source in both cases
+type part struct {
+	text string
+	ref  *ref
+}
+
+type ref struct {
+	name       string
+	fallback   string
+	hasDefault bool
+}
+
+// parse splits a value into literal text and references
+func parse(value string) ([]part, error) {
+	var parts []part
+	var literal strings.Builder
+	for i := 0; i < len(value); {
+		if strings.HasPrefix(value[i:], "$${") {
+			literal.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(value[i:], "${") {
+			literal.WriteByte(value[i])
+			i++
+			continue
+		}
+		end := closingBrace(value, i+2)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated ${ at position %d", i+1)
+		}
+		body := value[i+2 : end]
+		r := &ref{name: body}
+		if j := strings.Index(body, ":-"); j >= 0 {
+			r.name, r.fallback, r.hasDefault = body[:j], body[j+2:], true
+			if _, err := parse(r.fallback); err != nil {
+				return nil, err
+			}
+		}
+		if r.name == "" {
+			return nil, fmt.Errorf("empty reference at position %d", i+1)
+		}
+		if literal.Len() > 0 {
+			parts = append(parts, part{text: literal.String()})
+			literal.Reset()
+		}
+		parts = append(parts, part{text: value[i : end+1], ref: r})
+		i = end + 1
+	}
+	if literal.Len() > 0 {
+		parts = append(parts, part{text: literal.String()})
+	}
+	return parts, nil
+}
+
+// closingBrace finds the brace closing a reference whose body starts at start,
+// skipping nested references in defaults
+func closingBrace(value string, start int) int {
+	depth := 0
+	for i := start; i < len(value); i++ {
+		switch {
+		case strings.HasPrefix(value[i:], "${"):
+			depth++
+			i++
+		case value[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
@@ -0,0 +1,184 @@
+package interpolate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testScope looks up host variables in host and resolves other references
+// from refs, escaping their values like the service does for secrets
+func testScope(host, refs map[string]string) Scope {
+	return Scope{
+		Lookup: func(name string) (string, bool) {
+			value, ok := host[name]
+			return value, ok
+		},
+		Resolve: func(ref string) (string, error) {
+			value, ok := refs[ref]
+			if !ok {
+				return "", fmt.Errorf("unknown reference")
+			}
+			if strings.HasPrefix(ref, "secret:") {
+				return strings.ReplaceAll(value, "${", "$${"), nil
+			}
+			return value, nil
+		},
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		host map[string]string
+		refs map[string]string
+		want map[string]string
+		errs []string
+	}{
+		{
+			name: "references in any order",
+			env:  map[string]string{"A": "${B}/a", "B": "${C}/b", "C": "c"},
+			want: map[string]string{"A": "c/b/a", "B": "c/b", "C": "c"},
+		},
+		{
+			name: "host variables",
+			env:  map[string]string{"A": "${HOME}/src"},
+			host: map[string]string{"HOME": "/home/dev"},
+			want: map[string]string{"A": "/home/dev/src"},
+		},
+		{
+			name: "env shadows host",
+			env:  map[string]string{"A": "${B}", "B": "env"},
+			host: map[string]string{"B": "host"},
+			want: map[string]string{"A": "env", "B": "env"},
+		},
+		{
+			name: "default for unset and empty",
+			env:  map[string]string{"A": "${MISSING:-x}", "B": "${EMPTY:-y}", "EMPTY": ""},
+			want: map[string]string{"A": "x", "B": "y", "EMPTY": ""},
+		},
+		{
+			name: "nested defaults",
+			env:  map[string]string{"A": "${X:-${Y:-${Z:-deep}}}", "B": "${X:-${C:-no}}", "C": "c"},
+			want: map[string]string{"A": "deep", "B": "c", "C": "c"},
+		},
+		{
+			name: "default with reference to a set variable",
+			env:  map[string]string{"A": "${X:-${B}-suffix}", "B": "b"},
+			want: map[string]string{"A": "b-suffix", "B": "b"},
+		},
+		{
+			name: "escaped references stay literal",
+			env:  map[string]string{"A": "$${B}", "B": "b", "C": "$$${B}"},
+			want: map[string]string{"A": "${B}", "B": "b", "C": "$${B}"},
+		},
+		{
+			name: "escape inside a default",
+			env:  map[string]string{"A": "${X:-$${Y}}"},
+			want: map[string]string{"A": "${Y}"},
+		},
+		{
+			name: "resolved references are expanded",
+			env:  map[string]string{"A": "${service:api.url}/v1", "HOST": "localhost"},
+			refs: map[string]string{"service:api.url": "http://${HOST}:5000"},
+			want: map[string]string{"A": "http://localhost:5000/v1", "HOST": "localhost"},
+		},
+		{
+			name: "secret values are literal",
+			env:  map[string]string{"A": "${secret:db}", "B": "x"},
+			refs: map[string]string{"secret:db": "p${B}ss"},
+			want: map[string]string{"A": "p${B}ss", "B": "x"},
+		},
+		{
+			name: "failed resolve falls back to the default",
+			env:  map[string]string{"A": "${service:web.port:-3000}"},
+			want: map[string]string{"A": "3000"},
+		},
+		{
+			name: "undefined variable",
+			env:  map[string]string{"A": "x${MISSING}y"},
+			want: map[string]string{"A": "x${MISSING}y"},
+			errs: []string{"A: ${MISSING} is not defined"},
+		},
+		{
+			name: "unresolvable reference",
+			env:  map[string]string{"A": "${service:web.url}"},
+			want: map[string]string{"A": "${service:web.url}"},
+			errs: []string{"A: cannot resolve ${service:web.url}: unknown reference"},
+		},
+		{
+			name: "self reference",
+			env:  map[string]string{"A": "${A}"},
+			want: map[string]string{"A": "${A}"},
+			errs: []string{"A: reference cycle A -> A"},
+		},
+		{
+			name: "cycle is reported once",
+			env:  map[string]string{"A": "${B}", "B": "${C}", "C": "${A}", "D": "${A}"},
+			// The reference closing the cycle is left in place and passed up
+			want: map[string]string{"A": "${A}", "B": "${A}", "C": "${A}", "D": "${A}"},
+			errs: []string{"C: reference cycle A -> B -> C -> A"},
+		},
+		{
+			name: "cycle through a resolved reference",
+			env:  map[string]string{"A": "${service:api.url}"},
+			refs: map[string]string{"service:api.url": "${service:api.url}"},
+			want: map[string]string{"A": "${service:api.url}"},
+			errs: []string{"A: reference cycle ${service:api.url} -> ${service:api.url}"},
+		},
+		{
+			name: "syntax error",
+			env:  map[string]string{"A": "${B", "B": "b"},
+			want: map[string]string{"A": "${B", "B": "b"},
+			errs: []string{"A: unterminated ${ at position 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := make(map[string]string)
+			for k, v := range tt.env {
+				env[k] = v
+			}
+			var errs []string
+			for _, err := range Expand(env, testScope(tt.host, tt.refs)) {
+				errs = append(errs, err.Error())
+			}
+			if !reflect.DeepEqual(env, tt.want) {
+				t.Errorf("got %q, want %q", env, tt.want)
+			}
+			if !reflect.DeepEqual(errs, tt.errs) {
+				t.Errorf("got errors %q, want %q", errs, tt.errs)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{value: "plain"},
+		{value: "${A} and ${B:-${C}}"},
+		{value: "$${not a reference"},
+		{value: "${A", err: "unterminated ${ at position 1"},
+		{value: "x${}", err: "empty reference at position 2"},
+		{value: "${:-x}", err: "empty reference at position 1"},
+		{value: "${A:-${B}", err: "unterminated ${ at position 1"},
+		{value: "${A:-${}}", err: "empty reference at position 1"},
+	}
+
+	for _, tt := range tests {
+		err := Check(tt.value)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.err {
+			t.Errorf("Check(%q) = %q, want %q", tt.value, got, tt.err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package launchsettings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Profile represents a single launch profile in launchSettings.json
+type Profile struct {
+	CommandName          string            `json:"commandName"`
+	CommandLineArgs      string            `json:"commandLineArgs,omitempty"`
+	ApplicationURL       string            `json:"applicationUrl,omitempty"`
+	EnvironmentVariables map[string]string `json:"environmentVariables,omitempty"`
+}
+
+// NamedProfile is a profile together with its name
+type NamedProfile struct {
+	Name    string
+	Profile Profile
+}
+
+// LaunchSettings holds the profiles of a project in file order
+type LaunchSettings struct {
+	Profiles []NamedProfile
+}
+
+// FilePath returns the location of launchSettings.json for a project directory
+func FilePath(projectDir string) string {
+	return filepath.Join(projectDir, "Properties", "launchSettings.json")
+}
+
+// Load reads Properties/launchSettings.json from a project directory.
+// A missing file yields empty settings rather than an error.
+func Load(projectDir string) (*LaunchSettings, error) {
+	data, err := os.ReadFile(FilePath(projectDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &LaunchSettings{}, nil
+		}
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses the content of a launchSettings.json file. Visual Studio often
+// saves it with a UTF-8 byte order mark, which is skipped.
+func Parse(data []byte) (*LaunchSettings, error) {
+	var raw struct {
+		Profiles json.RawMessage `json:"profiles"`
+	}
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid launchSettings.json: %w", err)
+	}
+	settings := &LaunchSettings{}
+	if len(raw.Profiles) == 0 {
+		return settings, nil
+	}
+
+	// Walk the profiles object by hand so the file order is kept,
+	// dotnet run picks the first matching profile
+	dec := json.NewDecoder(bytes.NewReader(raw.Profiles))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("invalid launchSettings.json: profiles is not an object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid launchSettings.json: %w", err)
+		}
+		name, _ := tok.(string)
+		var profile Profile
+		if err := dec.Decode(&profile); err != nil {
+			return nil, fmt.Errorf("invalid launchSettings.json profile %q: %w", name, err)
+		}
+		settings.Profiles = append(settings.Profiles, NamedProfile{Name: name, Profile: profile})
+	}
+	return settings, nil
+}
+
+// DefaultProfile returns the profile dotnet run uses when none is specified,
+// which is the first one with commandName "Project"
+func (ls *LaunchSettings) DefaultProfile() *NamedProfile {
+	for i := range ls.Profiles {
+		if ls.Profiles[i].Profile.CommandName == "Project" {
+			return &ls.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// ApplicationURLs splits the semicolon separated applicationUrl of a profile
+func (p Profile) ApplicationURLs() []string {
+	var urls []string
+	for _, u := range strings.Split(p.ApplicationURL, ";") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// Profile returns the profile with the given name
+func (ls *LaunchSettings) Profile(name string) *NamedProfile {
+	for i := range ls.Profiles {
+		if ls.Profiles[i].Name == name {
+			return &ls.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// ProjectProfiles returns the profiles dotnet run can use, those with commandName "Project"
+func (ls *LaunchSettings) ProjectProfiles() []NamedProfile {
+	var profiles []NamedProfile
+	for _, profile := range ls.Profiles {
+		if profile.Profile.CommandName == "Project" {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+// Env returns the variables dotnet run sets for the profile, the
+// applicationUrl becomes ASPNETCORE_URLS unless the profile sets it itself
+func (p Profile) Env() map[string]string {
+	env := make(map[string]string, len(p.EnvironmentVariables)+1)
+	if urls := p.ApplicationURLs(); len(urls) > 0 {
+		env["ASPNETCORE_URLS"] = strings.Join(urls, ";")
+	}
+	for key, value := range p.EnvironmentVariables {
+		env[key] = value
+	}
+	return env
+}
+
+// Args splits the commandLineArgs of a profile like a shell would, honouring
+// double and single quotes. A backslash only escapes quotes, spaces and
+// itself so Windows paths are kept.
+func (p Profile) Args() []string {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	runes := []rune(p.CommandLineArgs)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && quote != '\'' && i+1 < len(runes) && strings.ContainsRune(`"' \\`, runes[i+1]):
+			i++
+			current.WriteRune(runes[i])
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
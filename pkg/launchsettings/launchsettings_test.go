@@ -0,0 +1,69 @@
+package launchsettings
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testSettings = `{
+  "profiles": {
+    "https": {
+      "commandName": "Project",
+      "applicationUrl": "https://localhost:7001;http://localhost:5001",
+      "environmentVariables": { "ASPNETCORE_ENVIRONMENT": "Development" }
+    },
+    "IIS Express": { "commandName": "IISExpress" },
+    "http": { "commandName": "Project", "applicationUrl": "http://localhost:5000" }
+  }
+}`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		want      []string // Profile names in file order
+		expectErr bool
+	}{
+		{name: "profiles in file order", data: testSettings, want: []string{"https", "IIS Express", "http"}},
+		{name: "byte order mark", data: "\xEF\xBB\xBF" + testSettings, want: []string{"https", "IIS Express", "http"}},
+		{name: "no profiles", data: `{"iisSettings": {}}`},
+		{name: "invalid JSON", data: `{"profiles": `, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := Parse([]byte(tt.data))
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", settings)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, p := range settings.Profiles {
+				names = append(names, p.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("got %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultProfile(t *testing.T) {
+	settings, err := Parse([]byte(testSettings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := settings.DefaultProfile()
+	if profile == nil || profile.Name != "https" {
+		t.Fatalf("got %+v, want the https profile", profile)
+	}
+	want := []string{"https://localhost:7001", "http://localhost:5001"}
+	if urls := profile.Profile.ApplicationURLs(); !reflect.DeepEqual(urls, want) {
+		t.Errorf("got %v, want %v", urls, want)
+	}
+}
@@ -0,0 +1,232 @@
+package portalloc
+
+import (
+	"fmt"
+	"sync"
+
+	"wails-launcher/pkg/portcheck"
+)
+
+// Default range used when the config does not specify one
+const (
+	DefaultRangeStart = 20000
+	DefaultRangeEnd   = 29999
+)
+
+// registration tracks the declared and allocated ports of one service
+type registration struct {
+	group     string
+	name      string
+	declared  []string
+	allocated map[string]int
+	url       string
+}
+
+// Allocator hands out free ports from a range to services' named ports.
+// Allocations are kept for the lifetime of the allocator so a service gets
+// the same ports again on restart as long as they are still free.
+type Allocator struct {
+	mu       sync.Mutex
+	start    int
+	end      int
+	next     int
+	services map[string]*registration
+}
+
+// NewAllocator creates an allocator for the inclusive range [start, end]
+func NewAllocator(start, end int) *Allocator {
+	a := &Allocator{services: make(map[string]*registration)}
+	a.SetRange(start, end)
+	return a
+}
+
+// SetRange changes the port range, falling back to the default for invalid values.
+// Existing allocations are kept.
+func (a *Allocator) SetRange(start, end int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if start <= 0 || end > 65535 || end < start {
+		start, end = DefaultRangeStart, DefaultRangeEnd
+	}
+	a.start, a.end, a.next = start, end, start
+}
+
+// Register records the group, name and declared ports of a service so that
+// other services can reference them before it has been started
+func (a *Allocator) Register(serviceId, groupId, serviceName string, portNames []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	reg, exists := a.services[serviceId]
+	if !exists {
+		reg = &registration{allocated: make(map[string]int)}
+		a.services[serviceId] = reg
+	}
+	reg.group = groupId
+	reg.name = serviceName
+	reg.declared = portNames
+	// Drop ports that are no longer declared
+	for name := range reg.allocated {
+		if !contains(portNames, name) {
+			delete(reg.allocated, name)
+		}
+	}
+}
+
+// Release forgets a service and its allocated ports
+func (a *Allocator) Release(serviceId string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.services, serviceId)
+}
+
+// SetURL records the URL a service listens on, so other services can refer to it
+func (a *Allocator) SetURL(serviceId, url string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if reg, exists := a.services[serviceId]; exists {
+		reg.url = url
+	}
+}
+
+// URL returns the URL recorded for the service called serviceName, as seen
+// from the service fromId
+func (a *Allocator) URL(fromId, serviceName string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	reg, err := a.find(fromId, serviceName)
+	if err != nil {
+		return "", err
+	}
+	if reg.url == "" {
+		return "", fmt.Errorf("the URL of service %q is not known until it runs", serviceName)
+	}
+	return reg.url, nil
+}
+
+// Allocate returns a port for every declared port of the service, reusing
+// earlier allocations that are still free
+func (a *Allocator) Allocate(serviceId string) (map[string]int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	reg, exists := a.services[serviceId]
+	if !exists {
+		return nil, fmt.Errorf("service %s is not registered", serviceId)
+	}
+
+	result := make(map[string]int)
+	for _, name := range reg.declared {
+		port, err := a.allocateLocked(reg, name, true)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = port
+	}
+	return result, nil
+}
+
+// Lookup returns the port allocated for a named port of the service called serviceName,
+// as seen from the service fromId. Ports of services that have not started yet are
+// reserved on first lookup. An empty portName selects the first declared port.
+func (a *Allocator) Lookup(fromId, serviceName, portName string) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	reg, err := a.find(fromId, serviceName)
+	if err != nil {
+		return 0, err
+	}
+	if portName == "" {
+		if len(reg.declared) == 0 {
+			return 0, fmt.Errorf("service %q declares no ports", serviceName)
+		}
+		portName = reg.declared[0]
+	}
+	if !contains(reg.declared, portName) {
+		return 0, fmt.Errorf("service %q has no port named %q", serviceName, portName)
+	}
+	return a.allocateLocked(reg, portName, false)
+}
+
+// Ports returns the current allocations of a service
+func (a *Allocator) Ports(serviceId string) map[string]int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	reg, exists := a.services[serviceId]
+	if !exists || len(reg.allocated) == 0 {
+		return nil
+	}
+	result := make(map[string]int, len(reg.allocated))
+	for name, port := range reg.allocated {
+		result[name] = port
+	}
+	return result
+}
+
+// find returns the registration of the service called serviceName. Names are
+// unique within a group, so the group of the service fromId is searched first.
+// Callers must hold the lock.
+func (a *Allocator) find(fromId, serviceName string) (*registration, error) {
+	var group string
+	if from, exists := a.services[fromId]; exists {
+		group = from.group
+	}
+	var matches []*registration
+	for _, r := range a.services {
+		if r.name != serviceName {
+			continue
+		}
+		if r.group == group {
+			return r, nil
+		}
+		matches = append(matches, r)
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("unknown service %q", serviceName)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("service %q is defined in %d other groups", serviceName, len(matches))
+	}
+}
+
+// allocateLocked returns the port for a named port of reg, allocating one if needed.
+// With recheck set, an existing allocation is replaced when something else took the port.
+// Callers must hold the lock.
+func (a *Allocator) allocateLocked(reg *registration, name string, recheck bool) (int, error) {
+	if port, ok := reg.allocated[name]; ok {
+		if !recheck || portcheck.IsPortFree(port) {
+			return port, nil
+		}
+	}
+
+	taken := make(map[int]bool)
+	for _, r := range a.services {
+		for _, port := range r.allocated {
+			taken[port] = true
+		}
+	}
+
+	size := a.end - a.start + 1
+	for i := 0; i < size; i++ {
+		port := a.next
+		a.next++
+		if a.next > a.end {
+			a.next = a.start
+		}
+		if taken[port] || !portcheck.IsPortFree(port) {
+			continue
+		}
+		reg.allocated[name] = port
+		return port, nil
+	}
+	return 0, fmt.Errorf("no free port left in range %d-%d", a.start, a.end)
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
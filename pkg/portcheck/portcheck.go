@@ -0,0 +1,176 @@
+package portcheck
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"wails-launcher/pkg/processsearch"
+)
+
+// Conflict describes a port that is already taken by another process
+type Conflict struct {
+	Port int    `json:"port"`
+	PID  string `json:"pid"` // Empty when the owner could not be determined
+	Cmd  string `json:"cmd"`
+	User string `json:"user"`
+}
+
+// Describe returns a human readable description of the port holder
+func (c Conflict) Describe() string {
+	if c.PID == "" {
+		return "an unknown process"
+	}
+	if c.User != "" {
+		return fmt.Sprintf("PID %s (%s, user %s)", c.PID, c.Cmd, c.User)
+	}
+	return fmt.Sprintf("PID %s (%s)", c.PID, c.Cmd)
+}
+
+// IsPortFree reports whether a TCP port can be bound on all interfaces
+func IsPortFree(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// FreePort asks the kernel for an unused TCP port
+func FreePort() (int, error) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// FindConflicts checks which of the given ports are already in use.
+// Holders running inside ownDir are ignored since they are leftovers of the
+// service itself and get cleaned up before it is spawned.
+func FindConflicts(ports []int, ownDir string) ([]Conflict, error) {
+	var conflicts []Conflict
+	seen := make(map[int]bool)
+	for _, port := range ports {
+		if seen[port] {
+			continue
+		}
+		seen[port] = true
+
+		holders, err := processsearch.FindProcessesByPort(port)
+		if err != nil {
+			return nil, err
+		}
+		if len(holders) == 0 {
+			// Nothing we can see, but the port may still be bound by another user or namespace
+			if !IsPortFree(port) {
+				conflicts = append(conflicts, Conflict{Port: port})
+			}
+			continue
+		}
+		for _, holder := range holders {
+			if isWithin(holder.Path, ownDir) {
+				continue
+			}
+			conflicts = append(conflicts, Conflict{
+				Port: port,
+				PID:  holder.PID,
+				Cmd:  holder.Cmd,
+				User: holder.User,
+			})
+		}
+	}
+	return conflicts, nil
+}
+
+// KillHolder terminates the process holding a conflicting port,
+// escalating to SIGKILL if it does not exit within a few seconds
+func KillHolder(c Conflict) error {
+	if c.PID == "" {
+		return fmt.Errorf("port %d is held by an unknown process", c.Port)
+	}
+	pid, err := strconv.Atoi(c.PID)
+	if err != nil {
+		return fmt.Errorf("invalid PID %q: %v", c.PID, err)
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if err := proc.Signal(syscall.SIGTERM); err != nil {
+		return err
+	}
+	for i := 0; i < 30; i++ {
+		time.Sleep(100 * time.Millisecond)
+		if proc.Signal(syscall.Signal(0)) != nil {
+			return nil
+		}
+	}
+	return proc.Signal(syscall.SIGKILL)
+}
+
+// PortFromURL extracts the port of a URL such as "http://localhost:5000",
+// "https://*:5001" or "http://+:80/"; wildcard hosts are accepted
+func PortFromURL(rawURL string) (int, bool) {
+	rest := rawURL
+	scheme := ""
+	if i := strings.Index(rest, "://"); i >= 0 {
+		scheme = strings.ToLower(rest[:i])
+		rest = rest[i+3:]
+	}
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.LastIndexByte(rest, ':'); i >= 0 && !strings.HasSuffix(rest, "]") {
+		if port, err := strconv.Atoi(rest[i+1:]); err == nil && port > 0 && port < 65536 {
+			return port, true
+		}
+		return 0, false
+	}
+	switch scheme {
+	case "http":
+		return 80, true
+	case "https":
+		return 443, true
+	}
+	return 0, false
+}
+
+// ReplacePort returns rawURL with its port swapped for newPort
+func ReplacePort(rawURL string, newPort int) string {
+	port, ok := PortFromURL(rawURL)
+	if !ok {
+		return rawURL
+	}
+	old := ":" + strconv.Itoa(port)
+	if i := strings.Index(rawURL, "://"); i >= 0 {
+		hostStart := i + 3
+		hostEnd := len(rawURL)
+		if j := strings.IndexAny(rawURL[hostStart:], "/?#"); j >= 0 {
+			hostEnd = hostStart + j
+		}
+		host := rawURL[hostStart:hostEnd]
+		if strings.HasSuffix(host, old) {
+			host = strings.TrimSuffix(host, old)
+		}
+		return rawURL[:hostStart] + host + ":" + strconv.Itoa(newPort) + rawURL[hostEnd:]
+	}
+	return strings.TrimSuffix(rawURL, old) + ":" + strconv.Itoa(newPort)
+}
+
+// isWithin reports whether path is dir or lies below it
+func isWithin(path, dir string) bool {
+	if path == "" || dir == "" {
+		return false
+	}
+	path = filepath.Clean(path)
+	dir = filepath.Clean(dir)
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
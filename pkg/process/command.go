@@ -0,0 +1,251 @@
+package process
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"wails-launcher/pkg/bridge"
+)
+
+// safeWord matches arguments the shell takes literally
+var safeWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// ShellQuote quotes an argument for sh when it needs it
+func ShellQuote(arg string) string {
+	if safeWord.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// urlRegex finds the URL a process reports listening on
+var urlRegex = regexp.MustCompile(`https?://[^\s"'<>]+`)
+
+// CommandService manages a shell command, like a Procfile entry. Several
+// commands usually share a directory, so unlike the npm service it does not
+// kill processes by their working directory before starting.
+type CommandService struct {
+	path       string
+	command    string // Shell command line
+	env        ServiceEnv
+	args       []string // Appended to the command as "$@"
+	mu         sync.Mutex
+	process    *exec.Cmd
+	urlSent    bool
+	logChan    chan LogEntry
+	urlChan    chan string
+	statusChan chan ServiceStatus
+}
+
+// NewCommandService creates a new CommandService
+func NewCommandService(path, command string, env ServiceEnv, args []string) *CommandService {
+	return &CommandService{
+		path:       path,
+		command:    command,
+		env:        env,
+		args:       args,
+		logChan:    make(chan LogEntry, 100),
+		urlChan:    make(chan string, 10),
+		statusChan: make(chan ServiceStatus, 10),
+	}
+}
+
+// SetCommand changes the command line
+func (cs *CommandService) SetCommand(command string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.command = command
+}
+
+// UpdateConfig updates the config
+func (cs *CommandService) UpdateConfig(path string, env ServiceEnv, args []string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.path = path
+	cs.env = env
+	cs.args = args
+}
+
+// Start runs the command
+func (cs *CommandService) Start() error {
+	cs.mu.Lock()
+	if cs.process != nil {
+		cs.mu.Unlock()
+		return fmt.Errorf("service already running")
+	}
+	cs.mu.Unlock()
+	cs.emitStatus(Starting)
+	cmd, err := cs.spawn()
+	if err != nil {
+		cs.emitLog(Err, fmt.Sprintf("Failed to spawn process: %v", err), "", "stdout")
+		cs.emitStatus(Error)
+		return err
+	}
+	cs.mu.Lock()
+	cs.process = cmd
+	cs.urlSent = false
+	cs.mu.Unlock()
+	cs.emitStatus(Initializing)
+	go cs.monitorProcess(cmd)
+	return nil
+}
+
+// StartWithoutBuild runs the command, commands have no separate build step
+func (cs *CommandService) StartWithoutBuild() error {
+	return cs.Start()
+}
+
+// Stop stops the command and the processes it started
+func (cs *CommandService) Stop() error {
+	cs.mu.Lock()
+	cmd := cs.process
+	cs.process = nil // monitorProcess reaps it without reporting an error
+	cs.mu.Unlock()
+	if cmd == nil {
+		cs.emitStatus(Stopped)
+		return nil
+	}
+	cs.emitStatus(Stopping)
+
+	// Send interrupt signal to bridge to allow it to kill children
+	cmd.Process.Signal(os.Interrupt)
+
+	// Give it a moment to cleanup
+	time.Sleep(500 * time.Millisecond)
+
+	if err := cmd.Process.Kill(); err != nil && err != os.ErrProcessDone {
+		return err
+	}
+	cs.emitStatus(Stopped)
+	return nil
+}
+
+// GetChannels returns the channels for listening
+func (cs *CommandService) GetChannels() (<-chan LogEntry, <-chan string, <-chan ServiceStatus) {
+	return cs.logChan, cs.urlChan, cs.statusChan
+}
+
+// spawn runs the command line with sh -c, the extra arguments become its
+// positional parameters
+func (cs *CommandService) spawn() (*exec.Cmd, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if strings.TrimSpace(cs.command) == "" {
+		return nil, fmt.Errorf("no command to run")
+	}
+	script := cs.command
+	if len(cs.args) > 0 {
+		script += ` "$@"`
+	}
+	argv := append([]string{"/bin/sh", "-c", script, "sh"}, cs.args...)
+	cs.emitLog(Inf, "Running "+cs.command, "", "launcher")
+
+	env := os.Environ()
+	for k, v := range cs.env {
+		env = append(env, k+"="+v)
+	}
+	// The bridge splits the command line again like a shell
+	for i, arg := range argv {
+		argv[i] = ShellQuote(arg)
+	}
+	cmd, err := bridge.CreateCommand(argv, env, cs.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bridge command: %v", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	go cs.readOutput(stdout, "stdout")
+	go cs.readOutput(stderr, "stderr")
+	return cmd, nil
+}
+
+// readOutput reads from pipe, the first URL printed is the service's URL
+func (cs *CommandService) readOutput(pipe io.ReadCloser, stream string) {
+	scanner := bufio.NewScanner(pipe)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "listen") || strings.Contains(lower, "running on") || strings.Contains(lower, "local:") {
+			if url := urlRegex.FindString(line); url != "" {
+				cs.mu.Lock()
+				send := !cs.urlSent
+				cs.urlSent = true
+				cs.mu.Unlock()
+				if send {
+					cs.emitURL(strings.TrimRight(url, "/."))
+				}
+			}
+		}
+		level := Inf
+		if stream == "stderr" {
+			level = Err
+		}
+		cs.emitLog(level, line, line, stream)
+	}
+}
+
+// monitorProcess waits for the command to exit
+func (cs *CommandService) monitorProcess(cmd *exec.Cmd) {
+	err := cmd.Wait()
+	cs.mu.Lock()
+	if cs.process != cmd {
+		cs.mu.Unlock()
+		return // Stopped
+	}
+	cs.process = nil
+	cs.mu.Unlock()
+	status := Stopped
+	if err != nil {
+		cs.emitLog(Inf, fmt.Sprintf("The command exited: %v", err), "", "launcher")
+		status = Error
+	}
+	cs.emitStatus(status)
+}
+
+// emitLog emits a log entry
+func (cs *CommandService) emitLog(level LogLevel, message, raw string, stream string) {
+	entry := LogEntry{
+		Timestamp: time.Now().Format(time.RFC3339),
+		Level:     level,
+		Message:   message,
+		Raw:       raw,
+		Stream:    stream,
+	}
+	select {
+	case cs.logChan <- entry:
+	default:
+	}
+}
+
+// emitURL emits a URL
+func (cs *CommandService) emitURL(url string) {
+	select {
+	case cs.urlChan <- url:
+	default:
+	}
+}
+
+// emitStatus emits a status change
+func (cs *CommandService) emitStatus(status ServiceStatus) {
+	select {
+	case cs.statusChan <- status:
+	default:
+	}
+}
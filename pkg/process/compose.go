@@ -0,0 +1,419 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"wails-launcher/pkg/executablesearch"
+)
+
+// composePollInterval is how often the container state is checked
+const composePollInterval = 2 * time.Second
+
+// httpPorts are container ports a published port is shown as a URL for
+var httpPorts = []int{80, 443, 3000, 4200, 5000, 5173, 8000, 8025, 8080, 8081, 8443, 9000, 15672}
+
+// ComposeService manages a service of a docker compose file through the
+// compose CLI: up and stop start and stop its container, logs -f follows its
+// output and the container state and health give its status
+type ComposeService struct {
+	path       string // Project directory
+	file       string // Compose file
+	service    string // Compose service name
+	env        ServiceEnv
+	args       []string // Extra arguments of compose up
+	mu         sync.Mutex
+	done       chan struct{} // Closed when the current run is no longer followed
+	logs       *exec.Cmd
+	logChan    chan LogEntry
+	urlChan    chan string
+	statusChan chan ServiceStatus
+}
+
+// NewComposeService creates a new ComposeService
+func NewComposeService(path, file, service string, env ServiceEnv, args []string) *ComposeService {
+	return &ComposeService{
+		path:       path,
+		file:       file,
+		service:    service,
+		env:        env,
+		args:       args,
+		logChan:    make(chan LogEntry, 100),
+		urlChan:    make(chan string, 10),
+		statusChan: make(chan ServiceStatus, 10),
+	}
+}
+
+// SetService changes the compose file and the service in it
+func (cs *ComposeService) SetService(file, service string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.file = file
+	cs.service = service
+}
+
+// UpdateConfig updates the config
+func (cs *ComposeService) UpdateConfig(path string, env ServiceEnv, args []string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.path = path
+	cs.env = env
+	cs.args = args
+}
+
+// Start builds the image when the service has a build section and starts the container
+func (cs *ComposeService) Start() error {
+	return cs.up("--build")
+}
+
+// StartWithoutBuild starts the container with the existing image
+func (cs *ComposeService) StartWithoutBuild() error {
+	return cs.up("--no-build")
+}
+
+// up runs compose up for the service in the background, then follows the container
+func (cs *ComposeService) up(buildFlag string) error {
+	cs.detach()
+	cs.emitStatus(Starting)
+	done := make(chan struct{})
+	cs.mu.Lock()
+	cs.done = done
+	args := append(append([]string{"up", "-d", buildFlag}, cs.args...), cs.service)
+	cs.mu.Unlock()
+
+	go func() {
+		if err := cs.run(args...); err != nil {
+			cs.emitLog(Err, fmt.Sprintf("compose up failed: %v", err), "", "stdout")
+			cs.emitStatus(Error)
+			return
+		}
+		select {
+		case <-done:
+			return // Stopped while starting
+		default:
+		}
+		if err := cs.followLogs(done); err != nil {
+			cs.emitLog(Warn, fmt.Sprintf("Cannot follow the logs: %v", err), "", "stdout")
+		}
+		cs.watch(done)
+	}()
+	return nil
+}
+
+// Stop stops the container
+func (cs *ComposeService) Stop() error {
+	cs.emitStatus(Stopping)
+	cs.detach()
+	cs.mu.Lock()
+	service := cs.service
+	cs.mu.Unlock()
+	if err := cs.run("stop", service); err != nil {
+		cs.emitStatus(Error)
+		return err
+	}
+	cs.emitStatus(Stopped)
+	return nil
+}
+
+// detach stops following the current run
+func (cs *ComposeService) detach() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.done != nil {
+		close(cs.done)
+		cs.done = nil
+	}
+	if cs.logs != nil {
+		cs.logs.Process.Kill()
+		cs.logs.Wait()
+		cs.logs = nil
+	}
+}
+
+// GetChannels returns the channels for listening
+func (cs *ComposeService) GetChannels() (<-chan LogEntry, <-chan string, <-chan ServiceStatus) {
+	return cs.logChan, cs.urlChan, cs.statusChan
+}
+
+var (
+	composeMu  sync.Mutex
+	composeCLI []string // Found compose command, looked up until found
+)
+
+// findCompose returns the docker compose plugin or the standalone docker-compose
+func findCompose() ([]string, error) {
+	composeMu.Lock()
+	defer composeMu.Unlock()
+	if composeCLI != nil {
+		return composeCLI, nil
+	}
+	if dockerPath, err := executablesearch.FindExecutable("docker"); err == nil && exec.Command(dockerPath, "compose", "version").Run() == nil {
+		composeCLI = []string{dockerPath, "compose"}
+	} else if composePath, err := executablesearch.FindExecutable("docker-compose"); err == nil {
+		composeCLI = []string{composePath}
+	} else {
+		return nil, fmt.Errorf("neither docker compose nor docker-compose found")
+	}
+	return composeCLI, nil
+}
+
+// command returns the compose command with the given arguments
+func (cs *ComposeService) command(args ...string) (*exec.Cmd, error) {
+	cli, err := findCompose()
+	if err != nil {
+		return nil, err
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	argv := append(slices.Clone(cli), "-f", cs.file, "--project-directory", cs.path)
+	cmd := exec.Command(argv[0], append(argv[1:], args...)...)
+	cmd.Dir = cs.path
+	// Compose interpolates ${VAR} in the file from its environment
+	cmd.Env = os.Environ()
+	for k, v := range cs.env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	return cmd, nil
+}
+
+// run runs a compose command and logs its output
+func (cs *ComposeService) run(args ...string) error {
+	cmd, err := cs.command(args...)
+	if err != nil {
+		return err
+	}
+	cs.emitLog(Inf, "Running "+strings.Join(cmd.Args, " "), "", "launcher")
+	output, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			cs.emitLog(Inf, line, line, "stderr")
+		}
+	}
+	return err
+}
+
+// followLogs streams the container output until the run is detached
+func (cs *ComposeService) followLogs(done chan struct{}) error {
+	cs.mu.Lock()
+	service := cs.service
+	cs.mu.Unlock()
+	cmd, err := cs.command("logs", "-f", "--no-color", "--no-log-prefix", "--tail", "100", service)
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	cs.mu.Lock()
+	select {
+	case <-done:
+		cs.mu.Unlock()
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil
+	default:
+		cs.logs = cmd
+	}
+	cs.mu.Unlock()
+	go cs.readOutput(stdout, "stdout")
+	go cs.readOutput(stderr, "stderr")
+	return nil
+}
+
+// readOutput reads from pipe
+func (cs *ComposeService) readOutput(pipe io.ReadCloser, stream string) {
+	scanner := bufio.NewScanner(pipe)
+	for scanner.Scan() {
+		line := scanner.Text()
+		level := Inf
+		if strings.Contains(strings.ToLower(line), "error") {
+			level = Err
+		}
+		cs.emitLog(level, line, line, stream)
+	}
+}
+
+// containerState is an entry of compose ps --format json
+type containerState struct {
+	State      string `json:"State"`  // running, exited, restarting, created, paused, dead
+	Health     string `json:"Health"` // healthy, unhealthy, starting or empty without a healthcheck
+	ExitCode   int    `json:"ExitCode"`
+	Publishers []struct {
+		TargetPort    int `json:"TargetPort"`
+		PublishedPort int `json:"PublishedPort"`
+	} `json:"Publishers"`
+}
+
+// watch polls the container state until it exits or the run is detached.
+// A container with a healthcheck is running once it reports healthy.
+func (cs *ComposeService) watch(done chan struct{}) {
+	ticker := time.NewTicker(composePollInterval)
+	defer ticker.Stop()
+	var last ServiceStatus
+	urlSent := false
+	for {
+		state, err := cs.state()
+		if err != nil {
+			cs.emitLog(Warn, fmt.Sprintf("Cannot read the container state: %v", err), "", "launcher")
+		} else if state != nil {
+			status := containerStatus(*state)
+			if status != last {
+				if status == Error && state.State == "running" {
+					cs.emitLog(Err, "The container is unhealthy", "", "launcher")
+				}
+				cs.emitStatus(status)
+				last = status
+			}
+			if !urlSent && status == Running {
+				if url := publishedURL(*state); url != "" {
+					cs.emitURL(url)
+				}
+				urlSent = true
+			}
+			if state.State == "exited" || state.State == "dead" {
+				cs.emitLog(Inf, fmt.Sprintf("The container exited with code %d", state.ExitCode), "", "launcher")
+				cs.mu.Lock()
+				if cs.done == done {
+					cs.mu.Unlock()
+					cs.detach()
+				} else {
+					cs.mu.Unlock()
+				}
+				return
+			}
+		}
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsUp reports whether the service's container is running
+func (cs *ComposeService) IsUp() bool {
+	state, err := cs.state()
+	return err == nil && state != nil && state.State == "running"
+}
+
+// state returns the state of the service's container, nil before it exists
+func (cs *ComposeService) state() (*containerState, error) {
+	cs.mu.Lock()
+	service := cs.service
+	cs.mu.Unlock()
+	cmd, err := cs.command("ps", "-a", "--format", "json", service)
+	if err != nil {
+		return nil, err
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	output = bytes.TrimSpace(output)
+	var states []containerState
+	if bytes.HasPrefix(output, []byte("[")) {
+		// Compose before 2.21 prints an array, later versions a line per container
+		if err := json.Unmarshal(output, &states); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, line := range bytes.Split(output, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			var state containerState
+			if err := json.Unmarshal(line, &state); err != nil {
+				return nil, err
+			}
+			states = append(states, state)
+		}
+	}
+	if len(states) == 0 {
+		return nil, nil
+	}
+	return &states[0], nil
+}
+
+// containerStatus maps the container state and health to a service status
+func containerStatus(state containerState) ServiceStatus {
+	switch state.State {
+	case "running":
+		switch state.Health {
+		case "starting":
+			return Starting
+		case "unhealthy":
+			return Error
+		}
+		return Running
+	case "restarting", "created":
+		return Starting
+	case "exited", "dead":
+		if state.ExitCode == 0 {
+			return Stopped
+		}
+		return Error
+	}
+	return Stopped
+}
+
+// publishedURL returns the URL of the first published HTTP port
+func publishedURL(state containerState) string {
+	for _, publisher := range state.Publishers {
+		if publisher.PublishedPort > 0 && slices.Contains(httpPorts, publisher.TargetPort) {
+			scheme := "http"
+			if publisher.TargetPort == 443 || publisher.TargetPort == 8443 {
+				scheme = "https"
+			}
+			return fmt.Sprintf("%s://localhost:%d", scheme, publisher.PublishedPort)
+		}
+	}
+	return ""
+}
+
+// emitLog emits a log entry
+func (cs *ComposeService) emitLog(level LogLevel, message, raw string, stream string) {
+	entry := LogEntry{
+		Timestamp: time.Now().Format(time.RFC3339),
+		Level:     level,
+		Message:   message,
+		Raw:       raw,
+		Stream:    stream,
+	}
+	select {
+	case cs.logChan <- entry:
+	default:
+	}
+}
+
+// emitURL emits a URL
+func (cs *ComposeService) emitURL(url string) {
+	select {
+	case cs.urlChan <- url:
+	default:
+	}
+}
+
+// emitStatus emits a status change
+func (cs *ComposeService) emitStatus(status ServiceStatus) {
+	select {
+	case cs.statusChan <- status:
+	default:
+	}
+}
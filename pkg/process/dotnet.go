@@ -18,6 +18,7 @@ import (
 type DotnetService struct {
 	path       string
 	env        ServiceEnv
+	args       []string
 	process    *exec.Cmd
 	logChan    chan LogEntry
 	urlChan    chan string
@@ -25,10 +26,11 @@ type DotnetService struct {
 }
 
 // NewDotnetService creates a new DotnetService
-func NewDotnetService(path string, env ServiceEnv) *DotnetService {
+func NewDotnetService(path string, env ServiceEnv, args []string) *DotnetService {
 	return &DotnetService{
 		path:       path,
 		env:        env,
+		args:       args,
 		logChan:    make(chan LogEntry, 100),
 		urlChan:    make(chan string, 10),
 		statusChan: make(chan ServiceStatus, 10),
@@ -36,9 +38,10 @@ func NewDotnetService(path string, env ServiceEnv) *DotnetService {
 }
 
 // UpdateConfig updates the config
-func (ds *DotnetService) UpdateConfig(path string, env ServiceEnv) {
+func (ds *DotnetService) UpdateConfig(path string, env ServiceEnv, args []string) {
 	ds.path = path
 	ds.env = env
+	ds.args = args
 }
 
 // Start starts the service
@@ -213,7 +216,7 @@ func (ds *DotnetService) spawn() (*exec.Cmd, error) {
 		env = append(env, k+"="+v)
 	}
 
-	cmd, err := bridge.CreateCommand([]string{dotnetPath, "run"}, env, ds.path)
+	cmd, err := bridge.CreateCommand(append([]string{ShellQuote(dotnetPath), "run"}, ds.quotedArgs()...), env, ds.path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bridge command: %v", err)
 	}
@@ -248,7 +251,7 @@ func (ds *DotnetService) spawnWithoutBuild() (*exec.Cmd, error) {
 		env = append(env, k+"="+v)
 	}
 
-	cmd, err := bridge.CreateCommand([]string{dotnetPath, "run", "--no-build"}, env, ds.path)
+	cmd, err := bridge.CreateCommand(append([]string{ShellQuote(dotnetPath), "run", "--no-build"}, ds.quotedArgs()...), env, ds.path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bridge command: %v", err)
 	}
@@ -270,6 +273,16 @@ func (ds *DotnetService) spawnWithoutBuild() (*exec.Cmd, error) {
 	return cmd, nil
 }
 
+// quotedArgs returns the arguments quoted for the bridge, which splits the
+// command line again like a shell
+func (ds *DotnetService) quotedArgs() []string {
+	quoted := make([]string, len(ds.args))
+	for i, arg := range ds.args {
+		quoted[i] = ShellQuote(arg)
+	}
+	return quoted
+}
+
 // readOutput reads from pipe and buffers multi-line log entries
 func (ds *DotnetService) readOutput(pipe io.ReadCloser, stream string) {
 	scanner := bufio.NewScanner(pipe)
@@ -16,31 +16,57 @@ import (
 	"wails-launcher/pkg/processsearch"
 )
 
-// NpmService manages npm processes
+// NpmService manages processes started through a package manager script
 type NpmService struct {
-	path       string
-	env        ServiceEnv
-	process    *exec.Cmd
-	logChan    chan LogEntry
-	urlChan    chan string
-	statusChan chan ServiceStatus
+	path        string
+	manager     string // npm, pnpm, yarn or bun
+	script      string // package.json script run by Start
+	startScript string // package.json script run by StartWithoutBuild
+	env         ServiceEnv
+	args        []string
+	process     *exec.Cmd
+	logChan     chan LogEntry
+	urlChan     chan string
+	statusChan  chan ServiceStatus
 }
 
-// NewNpmService creates a new NpmService
-func NewNpmService(path string, env ServiceEnv) *NpmService {
-	return &NpmService{
+// NewNpmService creates a new NpmService running scripts with a package
+// manager, npm run dev and npm run start when they are empty
+func NewNpmService(path, manager, script, startScript string, env ServiceEnv, args []string) *NpmService {
+	ns := &NpmService{
 		path:       path,
 		env:        env,
+		args:       args,
 		logChan:    make(chan LogEntry, 100),
 		urlChan:    make(chan string, 10),
 		statusChan: make(chan ServiceStatus, 10),
 	}
+	ns.SetScripts(manager, script, startScript)
+	return ns
+}
+
+// SetScripts changes the package manager and the scripts Start and
+// StartWithoutBuild run
+func (ns *NpmService) SetScripts(manager, script, startScript string) {
+	if manager == "" {
+		manager = "npm"
+	}
+	if script == "" {
+		script = "dev"
+	}
+	if startScript == "" {
+		startScript = "start"
+	}
+	ns.manager = manager
+	ns.script = script
+	ns.startScript = startScript
 }
 
 // UpdateConfig updates the config
-func (ns *NpmService) UpdateConfig(path string, env ServiceEnv) {
+func (ns *NpmService) UpdateConfig(path string, env ServiceEnv, args []string) {
 	ns.path = path
 	ns.env = env
+	ns.args = args
 }
 
 // Start starts the service
@@ -202,17 +228,27 @@ func (ns *NpmService) killProcess(pid string) error {
 	return nil
 }
 
-// spawn spawns the npm process
+// spawn runs the dev script
 func (ns *NpmService) spawn() (*exec.Cmd, error) {
-	npmPath, err := executablesearch.FindExecutable("npm")
+	return ns.spawnScript(ns.script)
+}
+
+// spawnWithoutBuild runs the start script
+func (ns *NpmService) spawnWithoutBuild() (*exec.Cmd, error) {
+	return ns.spawnScript(ns.startScript)
+}
+
+// spawnScript runs a package.json script with the package manager
+func (ns *NpmService) spawnScript(script string) (*exec.Cmd, error) {
+	npmPath, err := executablesearch.FindExecutable(ns.manager)
 	if err != nil {
-		return nil, fmt.Errorf("npm not found: %v", err)
+		return nil, fmt.Errorf("%s not found: %v", ns.manager, err)
 	}
-	ns.emitLog(Inf, fmt.Sprintf("Using npm at: %s", npmPath), "", "stdout")
+	ns.emitLog(Inf, fmt.Sprintf("Using %s at: %s", ns.manager, npmPath), "", "stdout")
 
 	env := os.Environ()
 
-	// Update PATH to include npm's directory, so it can find node
+	// Update PATH to include the package manager's directory, so it can find node
 	npmDir := filepath.Dir(npmPath)
 	pathFound := false
 	for i, e := range env {
@@ -230,7 +266,7 @@ func (ns *NpmService) spawn() (*exec.Cmd, error) {
 		env = append(env, k+"="+v)
 	}
 
-	cmd, err := bridge.CreateCommand([]string{npmPath, "run", "dev"}, env, ns.path)
+	cmd, err := bridge.CreateCommand(ns.command(npmPath, "run", script), env, ns.path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bridge command: %v", err)
 	}
@@ -252,54 +288,22 @@ func (ns *NpmService) spawn() (*exec.Cmd, error) {
 	return cmd, nil
 }
 
-// spawnWithoutBuild spawns the npm process without building
-func (ns *NpmService) spawnWithoutBuild() (*exec.Cmd, error) {
-	npmPath, err := executablesearch.FindExecutable("npm")
-	if err != nil {
-		return nil, fmt.Errorf("npm not found: %v", err)
-	}
-	ns.emitLog(Inf, fmt.Sprintf("Using npm at: %s", npmPath), "", "stdout")
-
-	env := os.Environ()
-
-	// Update PATH to include npm's directory, so it can find node
-	npmDir := filepath.Dir(npmPath)
-	pathFound := false
-	for i, e := range env {
-		if strings.HasPrefix(e, "PATH=") {
-			env[i] = "PATH=" + npmDir + ":" + strings.TrimPrefix(e, "PATH=")
-			pathFound = true
-			break
+// command appends the extra arguments. npm passes those after -- to the
+// script, pnpm, yarn and bun pass them on as they are and would hand the --
+// to the script too. The bridge splits the command line again like a shell,
+// so everything is quoted.
+func (ns *NpmService) command(npmPath string, script ...string) []string {
+	command := append([]string{npmPath}, script...)
+	if len(ns.args) > 0 {
+		if ns.manager == "npm" {
+			command = append(command, "--")
 		}
+		command = append(command, ns.args...)
 	}
-	if !pathFound {
-		env = append(env, "PATH="+npmDir)
+	for i, arg := range command {
+		command[i] = ShellQuote(arg)
 	}
-
-	for k, v := range ns.env {
-		env = append(env, k+"="+v)
-	}
-
-	cmd, err := bridge.CreateCommand([]string{npmPath, "start"}, env, ns.path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create bridge command: %v", err)
-	}
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, err
-	}
-	err = cmd.Start()
-	if err != nil {
-		return nil, err
-	}
-	go ns.readOutput(stdout, "stdout")
-	go ns.readOutput(stderr, "stderr")
-	return cmd, nil
+	return command
 }
 
 // readOutput reads from pipe
@@ -0,0 +1,300 @@
+package processsearch
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// procRoot is the mount point of the proc filesystem, overridable in tests
+var procRoot = "/proc"
+
+// lookupUser resolves a numeric UID to a user name, overridable in tests
+var lookupUser = func(uid string) string {
+	if u, err := user.LookupId(uid); err == nil {
+		return u.Username
+	}
+	return uid
+}
+
+// clockTicks is USER_HZ, the unit of the starttime field in /proc/<pid>/stat.
+// It is 100 on every mainstream Linux architecture.
+const clockTicks = 100
+
+// tcpListen is the socket state of a listening socket in /proc/net/tcp
+const tcpListen = "0A"
+
+// FindProcessesByCWD finds processes whose working directory is cwd or lies below it
+func FindProcessesByCWD(cwd string) ([]ProcessInfo, error) {
+	cwd = filepath.Clean(cwd)
+	processes, err := ListProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ProcessInfo
+	for _, proc := range processes {
+		if proc.Path == cwd || strings.HasPrefix(proc.Path, cwd+string(filepath.Separator)) {
+			results = append(results, proc)
+		}
+	}
+	return results, nil
+}
+
+// FindProcessesByName finds processes by command name
+func FindProcessesByName(name string) ([]ProcessInfo, error) {
+	processes, err := ListProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ProcessInfo
+	for _, proc := range processes {
+		if strings.Contains(proc.Cmd, name) {
+			results = append(results, proc)
+		}
+	}
+	return results, nil
+}
+
+// FindProcessesByPort finds processes listening on a TCP port
+func FindProcessesByPort(port int) ([]ProcessInfo, error) {
+	processes, err := ListProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ProcessInfo
+	for _, proc := range processes {
+		for _, p := range proc.Ports {
+			if p == port {
+				results = append(results, proc)
+				break
+			}
+		}
+	}
+	return results, nil
+}
+
+// ListProcesses returns every process visible in /proc except the current one.
+// Processes that exit during the scan or whose details cannot be read are skipped.
+func ListProcesses() ([]ProcessInfo, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	bootTime, err := readBootTime()
+	if err != nil {
+		return nil, err
+	}
+	ports := listeningPortsByInode()
+	self := strconv.Itoa(os.Getpid())
+
+	var results []ProcessInfo
+	for _, entry := range entries {
+		pid := entry.Name()
+		if !entry.IsDir() || !isNumeric(pid) || pid == self {
+			continue
+		}
+		proc, err := readProcess(pid, bootTime)
+		if err != nil {
+			continue
+		}
+		proc.Ports = processPorts(pid, ports)
+		results = append(results, proc)
+	}
+	return results, nil
+}
+
+// readProcess reads the details of a single process from /proc/<pid>
+func readProcess(pid string, bootTime time.Time) (ProcessInfo, error) {
+	dir := filepath.Join(procRoot, pid)
+
+	statData, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+	comm, ppid, startTicks, err := parseStat(string(statData))
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+
+	proc := ProcessInfo{
+		PID:       pid,
+		PPID:      ppid,
+		Cmd:       comm,
+		StartTime: bootTime.Add(time.Duration(startTicks) * time.Second / clockTicks),
+	}
+
+	// Kernel threads and zombies have an empty cmdline, keep the comm name for those
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		if args := strings.TrimRight(string(cmdline), "\x00"); args != "" {
+			proc.Cmd = strings.ReplaceAll(args, "\x00", " ")
+		}
+	}
+
+	// cwd is only readable for our own processes unless we are privileged
+	if cwd, err := os.Readlink(filepath.Join(dir, "cwd")); err == nil {
+		proc.Path = cwd
+	}
+
+	if uid, err := readUID(filepath.Join(dir, "status")); err == nil {
+		proc.User = lookupUser(uid)
+	}
+
+	return proc, nil
+}
+
+// parseStat extracts the command name, parent PID and start time from /proc/<pid>/stat.
+// The command name is wrapped in parentheses and may itself contain spaces or parentheses.
+func parseStat(stat string) (comm string, ppid string, startTicks uint64, err error) {
+	lparen := strings.IndexByte(stat, '(')
+	rparen := strings.LastIndexByte(stat, ')')
+	if lparen < 0 || rparen < lparen {
+		return "", "", 0, fmt.Errorf("malformed stat: %q", stat)
+	}
+	comm = stat[lparen+1 : rparen]
+
+	// Fields after the command name start at field 3 (state)
+	fields := strings.Fields(stat[rparen+1:])
+	if len(fields) < 20 {
+		return "", "", 0, fmt.Errorf("malformed stat: %q", stat)
+	}
+	ppid = fields[1]
+	startTicks, err = strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("malformed stat starttime: %v", err)
+	}
+	return comm, ppid, startTicks, nil
+}
+
+// readUID reads the real UID from /proc/<pid>/status
+func readUID(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Uid:") {
+			fields := strings.Fields(strings.TrimPrefix(line, "Uid:"))
+			if len(fields) > 0 {
+				return fields[0], nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no Uid line in %s", path)
+}
+
+// readBootTime reads the system boot time from /proc/stat
+func readBootTime() (time.Time, error) {
+	file, err := os.Open(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "btime ") {
+			secs, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(line, "btime ")), 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, fmt.Errorf("no btime line in %s", filepath.Join(procRoot, "stat"))
+}
+
+// listeningPortsByInode maps socket inodes to the TCP ports they listen on
+func listeningPortsByInode() map[string]int {
+	ports := make(map[string]int)
+	for _, name := range []string{"tcp", "tcp6"} {
+		parseTCPTable(filepath.Join(procRoot, "net", name), ports)
+	}
+	return ports
+}
+
+// parseTCPTable adds the listening sockets of a /proc/net/tcp{,6} table to ports
+func parseTCPTable(path string, ports map[string]int) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // Skip header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != tcpListen {
+			continue
+		}
+		colon := strings.LastIndexByte(fields[1], ':')
+		if colon < 0 {
+			continue
+		}
+		port, err := strconv.ParseUint(fields[1][colon+1:], 16, 16)
+		if err != nil {
+			continue
+		}
+		ports[fields[9]] = int(port)
+	}
+}
+
+// processPorts returns the listening ports owned by a process via its open socket descriptors
+func processPorts(pid string, ports map[string]int) []int {
+	if len(ports) == 0 {
+		return nil
+	}
+	fdDir := filepath.Join(procRoot, pid, "fd")
+	fds, err := os.ReadDir(fdDir)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[int]bool)
+	var result []int
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+		if err != nil || !strings.HasPrefix(target, "socket:[") {
+			continue
+		}
+		inode := strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")
+		if port, ok := ports[inode]; ok && !seen[port] {
+			seen[port] = true
+			result = append(result, port)
+		}
+	}
+	return result
+}
+
+// isNumeric reports whether s consists only of ASCII digits
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
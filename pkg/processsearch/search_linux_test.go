@@ -0,0 +1,316 @@
+package processsearch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBootTime = 1700000000
+
+// fakeProcess describes a process to lay out under a fake /proc root
+type fakeProcess struct {
+	pid     string
+	comm    string
+	ppid    string
+	start   uint64 // clock ticks since boot
+	uid     string
+	cmdline []string
+	cwd     string
+	sockets []string // socket inodes held open
+}
+
+// newFakeProc builds a fake /proc tree and points procRoot at it for the duration of the test
+func newFakeProc(t *testing.T, procs []fakeProcess, tcp, tcp6 string) string {
+	t.Helper()
+	root := t.TempDir()
+
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(filepath.Join(root, "stat"), "cpu  1 2 3 4\nbtime 1700000000\nprocesses 42\n")
+	tcpHeader := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	write(filepath.Join(root, "net", "tcp"), tcpHeader+tcp)
+	write(filepath.Join(root, "net", "tcp6"), tcpHeader+tcp6)
+
+	for _, p := range procs {
+		dir := filepath.Join(root, p.pid)
+		// Fields 3..22 of stat: state, ppid, then 17 filler fields, then starttime
+		fields := []string{"S", p.ppid}
+		for i := 0; i < 17; i++ {
+			fields = append(fields, "0")
+		}
+		fields = append(fields, strconv.FormatUint(p.start, 10))
+		write(filepath.Join(dir, "stat"), p.pid+" ("+p.comm+") "+strings.Join(fields, " ")+" 0 0\n")
+		write(filepath.Join(dir, "status"), "Name:\t"+p.comm+"\nUid:\t"+p.uid+"\t"+p.uid+"\t"+p.uid+"\t"+p.uid+"\n")
+		cmdline := ""
+		if len(p.cmdline) > 0 {
+			cmdline = strings.Join(p.cmdline, "\x00") + "\x00"
+		}
+		write(filepath.Join(dir, "cmdline"), cmdline)
+		if p.cwd != "" {
+			if err := os.Symlink(p.cwd, filepath.Join(dir, "cwd")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.MkdirAll(filepath.Join(dir, "fd"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for i, inode := range p.sockets {
+			if err := os.Symlink("socket:["+inode+"]", filepath.Join(dir, "fd", strconv.Itoa(i+3))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Symlink("/dev/null", filepath.Join(dir, "fd", "0")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Non-process entries must be ignored
+	write(filepath.Join(root, "self", "stat"), "garbage")
+	write(filepath.Join(root, "uptime"), "1.0 1.0\n")
+
+	oldRoot, oldLookup := procRoot, lookupUser
+	procRoot = root
+	lookupUser = func(uid string) string {
+		if uid == "1000" {
+			return "dev"
+		}
+		return uid
+	}
+	t.Cleanup(func() {
+		procRoot, lookupUser = oldRoot, oldLookup
+	})
+	return root
+}
+
+var testProcesses = []fakeProcess{
+	{
+		pid:     "100",
+		comm:    "dotnet",
+		ppid:    "1",
+		start:   500,
+		uid:     "1000",
+		cmdline: []string{"dotnet", "run", "--project", "My Api.csproj"},
+		cwd:     "/home/dev/src/My Api",
+		sockets: []string{"2001", "2002"},
+	},
+	{
+		pid:     "101",
+		comm:    "node (vite)",
+		ppid:    "100",
+		start:   1234,
+		uid:     "1000",
+		cmdline: []string{"node", "/home/dev/src/web/node_modules/.bin/vite"},
+		cwd:     "/home/dev/src/My Api/ClientApp",
+		sockets: []string{"3001"},
+	},
+	{
+		pid:     "102",
+		comm:    "bash",
+		ppid:    "1",
+		start:   10,
+		uid:     "0",
+		cmdline: []string{"bash"},
+		cwd:     "/home/dev/src/My Api-old",
+	},
+	{
+		pid:  "2",
+		comm: "kthreadd",
+		ppid: "0",
+		uid:  "0",
+	},
+}
+
+const testTCP = "" +
+	// 0.0.0.0:5000 LISTEN inode 2001
+	"   0: 00000000:1388 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 2001 1 0000000000000000 100 0 0 10 0\n" +
+	// established connection on inode 2002, not a listener
+	"   1: 0100007F:1388 0100007F:D431 01 00000000:00000000 00:00000000 00000000  1000        0 2002 1 0000000000000000 20 4 30 10 -1\n"
+
+const testTCP6 = "" +
+	// [::]:5173 LISTEN inode 3001
+	"   0: 00000000000000000000000000000000:1435 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 3001 1 0000000000000000 100 0 0 10 0\n"
+
+func TestListProcesses(t *testing.T) {
+	newFakeProc(t, testProcesses, testTCP, testTCP6)
+
+	procs, err := ListProcesses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != len(testProcesses) {
+		t.Fatalf("got %d processes, want %d", len(procs), len(testProcesses))
+	}
+
+	byPID := make(map[string]ProcessInfo)
+	for _, p := range procs {
+		byPID[p.PID] = p
+	}
+
+	api := byPID["100"]
+	want := ProcessInfo{
+		PID:       "100",
+		PPID:      "1",
+		User:      "dev",
+		Cmd:       "dotnet run --project My Api.csproj",
+		Path:      "/home/dev/src/My Api",
+		StartTime: time.Unix(testBootTime, 0).Add(5 * time.Second),
+		Ports:     []int{5000},
+	}
+	if !reflect.DeepEqual(api, want) {
+		t.Errorf("process 100:\n got %+v\nwant %+v", api, want)
+	}
+
+	vite := byPID["101"]
+	if vite.PPID != "100" {
+		t.Errorf("vite PPID = %q, want 100", vite.PPID)
+	}
+	if !reflect.DeepEqual(vite.Ports, []int{5173}) {
+		t.Errorf("vite ports = %v, want [5173]", vite.Ports)
+	}
+	if wantStart := time.Unix(testBootTime, 0).Add(12340 * time.Millisecond); !vite.StartTime.Equal(wantStart) {
+		t.Errorf("vite start = %v, want %v", vite.StartTime, wantStart)
+	}
+
+	kthread := byPID["2"]
+	if kthread.Cmd != "kthreadd" {
+		t.Errorf("kernel thread Cmd = %q, want comm fallback", kthread.Cmd)
+	}
+	if kthread.Path != "" || kthread.Ports != nil {
+		t.Errorf("kernel thread should have no cwd or ports, got %+v", kthread)
+	}
+	if kthread.User != "0" {
+		t.Errorf("unresolved user = %q, want raw uid", kthread.User)
+	}
+}
+
+func TestFindProcessesByCWD(t *testing.T) {
+	newFakeProc(t, testProcesses, testTCP, testTCP6)
+
+	procs, err := FindProcessesByCWD("/home/dev/src/My Api/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pids []string
+	for _, p := range procs {
+		pids = append(pids, p.PID)
+	}
+	// Sibling directory sharing the prefix ("My Api-old") must not match
+	if !reflect.DeepEqual(pids, []string{"100", "101"}) {
+		t.Errorf("got PIDs %v, want [100 101]", pids)
+	}
+}
+
+func TestFindProcessesByName(t *testing.T) {
+	newFakeProc(t, testProcesses, testTCP, testTCP6)
+
+	procs, err := FindProcessesByName("vite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != 1 || procs[0].PID != "101" {
+		t.Errorf("got %+v, want only PID 101", procs)
+	}
+
+	procs, err = FindProcessesByName("no-such-command")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != 0 {
+		t.Errorf("got %+v, want none", procs)
+	}
+}
+
+func TestFindProcessesByPort(t *testing.T) {
+	newFakeProc(t, testProcesses, testTCP, testTCP6)
+
+	tests := []struct {
+		port int
+		pids []string
+	}{
+		{port: 5000, pids: []string{"100"}},
+		{port: 5173, pids: []string{"101"}},
+		{port: 8080, pids: nil},
+	}
+	for _, tt := range tests {
+		procs, err := FindProcessesByPort(tt.port)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var pids []string
+		for _, p := range procs {
+			pids = append(pids, p.PID)
+		}
+		if !reflect.DeepEqual(pids, tt.pids) {
+			t.Errorf("port %d: got PIDs %v, want %v", tt.port, pids, tt.pids)
+		}
+	}
+}
+
+func TestListProcessesMissingRoot(t *testing.T) {
+	oldRoot := procRoot
+	procRoot = filepath.Join(t.TempDir(), "missing")
+	defer func() { procRoot = oldRoot }()
+
+	if _, err := ListProcesses(); err == nil {
+		t.Error("expected error for missing proc root")
+	}
+}
+
+func TestParseStat(t *testing.T) {
+	tests := []struct {
+		name      string
+		stat      string
+		comm      string
+		ppid      string
+		start     uint64
+		expectErr bool
+	}{
+		{
+			name:  "plain",
+			stat:  "42 (node) S 7 42 42 0 -1 4194560 1 0 0 0 0 0 0 0 20 0 1 0 9876 0 0",
+			comm:  "node",
+			ppid:  "7",
+			start: 9876,
+		},
+		{
+			name:  "comm with spaces and parens",
+			stat:  "43 (a) b (c) R 8 43 43 0 -1 4194560 1 0 0 0 0 0 0 0 20 0 1 0 55 0 0",
+			comm:  "a) b (c",
+			ppid:  "8",
+			start: 55,
+		},
+		{name: "truncated", stat: "44 (x) S 1 2 3", expectErr: true},
+		{name: "no parens", stat: "45 x S 1", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comm, ppid, start, err := parseStat(tt.stat)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if comm != tt.comm || ppid != tt.ppid || start != tt.start {
+				t.Errorf("got (%q, %q, %d), want (%q, %q, %d)", comm, ppid, start, tt.comm, tt.ppid, tt.start)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package procfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"wails-launcher/pkg/dotenv"
+)
+
+// FileName is the name of a Procfile, EnvFileName the dotenv file foreman
+// loads next to it
+const (
+	FileName    = "Procfile"
+	EnvFileName = ".env"
+)
+
+// Foreman gives each process type a PORT, starting at PortBase and stepping by PortStep
+const (
+	PortBase = 5000
+	PortStep = 100
+)
+
+// Entry is a process type of a Procfile
+type Entry struct {
+	Name    string
+	Command string
+}
+
+// Listens reports whether the process serves on its PORT: the web process
+// type and commands referring to $PORT
+func (e Entry) Listens() bool {
+	return e.Name == "web" || strings.Contains(e.Command, "PORT")
+}
+
+// lineRegex matches "name: command", names are letters, digits, _ and -
+var lineRegex = regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.+)$`)
+
+// Parse parses a Procfile. Blank lines and # comments are skipped, a process
+// type may only appear once.
+func Parse(data []byte) ([]Entry, error) {
+	var entries []Entry
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		m := lineRegex.FindStringSubmatch(text)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: expected name: command", line)
+		}
+		if seen[m[1]] {
+			return nil, fmt.Errorf("line %d: %s is defined twice", line, m[1])
+		}
+		seen[m[1]] = true
+		entries = append(entries, Entry{Name: m[1], Command: strings.TrimSpace(m[2])})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Procfile is a parsed Procfile
+type Procfile struct {
+	Path    string // Absolute path of the Procfile
+	Entries []Entry
+}
+
+// Dir returns the directory the processes run in
+func (p *Procfile) Dir() string {
+	return filepath.Dir(p.Path)
+}
+
+// Load reads a Procfile, or the Procfile of a directory
+func Load(path string) (*Procfile, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		path = filepath.Join(path, FileName)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return &Procfile{Path: path, Entries: entries}, nil
+}
+
+// BasePort returns the PORT of the first process, set by PORT in the .env of
+// dir like foreman does, PortBase otherwise
+func BasePort(dir string) int {
+	env, err := dotenv.ReadFile(filepath.Join(dir, EnvFileName))
+	if err != nil {
+		return PortBase
+	}
+	if port, err := strconv.Atoi(env["PORT"]); err == nil && port > 0 && port <= 65535 {
+		return port
+	}
+	return PortBase
+}
+
+// nameRegex matches the characters a process type name cannot have
+var nameRegex = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Name turns a service name into a process type name, "My API" -> "My-API"
+func Name(name string) string {
+	name = strings.Trim(nameRegex.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		return "process"
+	}
+	return name
+}
+
+// Format writes entries as a Procfile
+func Format(entries []Entry) []byte {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		fmt.Fprintf(&buf, "%s: %s\n", entry.Name, entry.Command)
+	}
+	return buf.Bytes()
+}
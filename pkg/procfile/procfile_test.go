@@ -0,0 +1,179 @@
+package procfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		want      []Entry
+		expectErr bool
+	}{
+		{
+			name: "entries in order",
+			data: "web: bundle exec rails s -p $PORT\nworker:bin/jobs --queue=default\n",
+			want: []Entry{
+				{Name: "web", Command: "bundle exec rails s -p $PORT"},
+				{Name: "worker", Command: "bin/jobs --queue=default"},
+			},
+		},
+		{
+			name: "blank lines, comments and spacing",
+			data: "# processes\n\n  api_v2:  node server.js  \r\n\t# release: skip\nclock-1: ./clock\n",
+			want: []Entry{
+				{Name: "api_v2", Command: "node server.js"},
+				{Name: "clock-1", Command: "./clock"},
+			},
+		},
+		{
+			name: "colons in the command",
+			data: "web: serve --listen=tcp://0.0.0.0:$PORT\n",
+			want: []Entry{{Name: "web", Command: "serve --listen=tcp://0.0.0.0:$PORT"}},
+		},
+		{name: "empty", data: "# nothing\n\n"},
+		{name: "missing command", data: "web:\n", expectErr: true},
+		{name: "invalid name", data: "my web: serve\n", expectErr: true},
+		{name: "no colon", data: "web serve\n", expectErr: true},
+		{name: "duplicate name", data: "web: a\nweb: b\n", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := Parse([]byte(tt.data))
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(entries, tt.want) {
+				t.Errorf("got %v, want %v", entries, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrorLine(t *testing.T) {
+	_, err := Parse([]byte("web: a\n\n# note\nweb: b\n"))
+	if err == nil || err.Error() != "line 4: web is defined twice" {
+		t.Errorf("got %v, want the error on line 4", err)
+	}
+}
+
+func TestListens(t *testing.T) {
+	tests := []struct {
+		entry Entry
+		want  bool
+	}{
+		{Entry{Name: "web", Command: "rails s"}, true},
+		{Entry{Name: "api", Command: "node server.js --port $PORT"}, true},
+		{Entry{Name: "api", Command: "node server.js --port ${PORT}"}, true},
+		{Entry{Name: "worker", Command: "bin/jobs"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.entry.Listens(); got != tt.want {
+			t.Errorf("%v.Listens() = %v, want %v", tt.entry, got, tt.want)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"web", "web"},
+		{"My API", "My-API"},
+		{"@scope/app", "scope-app"},
+		{"  api (v2) ", "api-v2"},
+		{"***", "process"},
+		{"", "process"},
+	}
+
+	for _, tt := range tests {
+		if got := Name(tt.name); got != tt.want {
+			t.Errorf("Name(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{Name: "web", Command: "cd 'My App' && npm run dev"},
+		{Name: "worker", Command: "bin/jobs"},
+	}
+	data := Format(entries)
+	if want := "web: cd 'My App' && npm run dev\nworker: bin/jobs\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, entries) {
+		t.Errorf("got %v, want %v", parsed, entries)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("web: serve\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{dir, filepath.Join(dir, FileName)} {
+		file, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if file.Path != filepath.Join(dir, FileName) || file.Dir() != dir {
+			t.Errorf("Load(%q): got path %q", path, file.Path)
+		}
+		if want := []Entry{{Name: "web", Command: "serve"}}; !reflect.DeepEqual(file.Entries, want) {
+			t.Errorf("Load(%q): got %v, want %v", path, file.Entries, want)
+		}
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for a missing Procfile")
+	}
+}
+
+func TestBasePort(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string // Content of the .env file, none when empty
+		want int
+	}{
+		{name: "no .env", want: PortBase},
+		{name: "PORT set", env: "PORT=3000\n", want: 3000},
+		{name: "quoted PORT", env: "PORT=\"8080\" # web\n", want: 8080},
+		{name: "no PORT", env: "OTHER=1\n", want: PortBase},
+		{name: "invalid PORT", env: "PORT=web\n", want: PortBase},
+		{name: "PORT out of range", env: "PORT=70000\n", want: PortBase},
+		{name: "unparsable .env", env: "PORT=\"3000\n", want: PortBase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.env != "" {
+				if err := os.WriteFile(filepath.Join(dir, EnvFileName), []byte(tt.env), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := BasePort(dir); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
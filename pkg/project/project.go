@@ -0,0 +1,501 @@
+package project
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"wails-launcher/pkg/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File names looked up at a project root. The shared file is checked into the
+// repository, the local one holds personal overrides and should be gitignored.
+const (
+	FileName      = ".launcher.yaml"
+	LocalFileName = ".launcher.local.yaml"
+)
+
+// File is the content of a project or overrides file, keyed by stable names
+// instead of generated IDs
+type File struct {
+	Groups map[string]Group `yaml:"groups"`
+}
+
+// Group describes a group of services in a project file
+type Group struct {
+	Name     string             `yaml:"name,omitempty"`
+	Env      config.ServiceEnv  `yaml:"env,omitempty"`
+	EnvFiles []string           `yaml:"envFiles,omitempty"` // Relative to the project root
+	Services map[string]Service `yaml:"services,omitempty"`
+}
+
+// Service describes a service in a project file. Paths are relative to the project root.
+type Service struct {
+	Name           string             `yaml:"name,omitempty"` // Defaults to the service key
+	Path           string             `yaml:"path,omitempty"` // Defaults to the project root
+	Type           string             `yaml:"type,omitempty"`
+	Env            config.ServiceEnv  `yaml:"env,omitempty"`
+	Ports          []int              `yaml:"ports,omitempty"`
+	NamedPorts     []config.PortSpec  `yaml:"namedPorts,omitempty"`
+	Proxy          *config.ProxyRoute `yaml:"proxy,omitempty"`
+	DependsOn      []string           `yaml:"dependsOn,omitempty"`
+	EnvFiles       []string           `yaml:"envFiles,omitempty"` // Relative to the service path
+	LaunchProfile  string             `yaml:"launchProfile,omitempty"`
+	Tags           []string           `yaml:"tags,omitempty"`
+	PackageManager string             `yaml:"packageManager,omitempty"`
+	Script         string             `yaml:"script,omitempty"`
+	Args           []string           `yaml:"args,omitempty"`
+	ComposeFile    string             `yaml:"composeFile,omitempty"` // Relative to the service path
+	ComposeService string             `yaml:"composeService,omitempty"`
+	Command        string             `yaml:"command,omitempty"`
+}
+
+// Find looks for a project file in dir and its parents and returns the project root
+func Find(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, FileName)); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Root returns the project root for a path to a project directory or file
+func Root(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+	if _, err := os.Stat(filepath.Join(path, FileName)); err != nil {
+		return "", fmt.Errorf("no %s in %s", FileName, path)
+	}
+	return path, nil
+}
+
+// ID derives a stable ID for a group or service from the project root and its keys,
+// so reloading a project keeps its IDs. The root keeps projects with the same keys apart,
+// so IDs differ between checkouts and are never written to the project files.
+func ID(root string, keys ...string) string {
+	sum := sha256.Sum256([]byte(root + "\x00" + strings.Join(keys, "\x00")))
+	return fmt.Sprintf("%x", sum[:16])
+}
+
+// Load reads the project file at root, applies the local overrides and returns
+// the groups keyed by their stable IDs with absolute service paths
+func Load(root string) (map[string]config.GroupConfig, error) {
+	shared, err := readFile(filepath.Join(root, FileName))
+	if err != nil {
+		return nil, err
+	}
+	local, err := readFile(filepath.Join(root, LocalFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	merged := merge(shared, local)
+
+	groups := make(map[string]config.GroupConfig)
+	for groupKey, group := range merged.Groups {
+		groupConfig := config.GroupConfig{
+			Name:     group.Name,
+			Env:      copyEnv(group.Env),
+			Services: make(map[string]config.ServiceConfig),
+			EnvFiles: group.EnvFiles,
+			Project:  root,
+		}
+		if groupConfig.Name == "" {
+			groupConfig.Name = groupKey
+		}
+		for serviceKey, svc := range group.Services {
+			groupConfig.Services[ID(root, groupKey, serviceKey)] = svc.toConfig(root, serviceKey)
+		}
+		groups[ID(root, groupKey)] = groupConfig
+	}
+	return groups, nil
+}
+
+// Defines reports whether the shared project file at root defines the service
+func Defines(root string, serviceId string) bool {
+	shared, err := readFile(filepath.Join(root, FileName))
+	if err != nil {
+		return false
+	}
+	for groupKey, group := range shared.Groups {
+		for serviceKey := range group.Services {
+			if ID(root, groupKey, serviceKey) == serviceId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SaveLocal writes the differences between the shared project file and groups
+// (keyed by ID, as returned by Load and edited since) to the local overrides file
+func SaveLocal(root string, groups map[string]config.GroupConfig) error {
+	shared, err := readFile(filepath.Join(root, FileName))
+	if err != nil {
+		return err
+	}
+
+	overrides := File{Groups: make(map[string]Group)}
+	for groupKey, sharedGroup := range shared.Groups {
+		edited, exists := groups[ID(root, groupKey)]
+		if !exists {
+			continue
+		}
+		override := Group{Env: diffEnv(sharedGroup.Env, edited.Env), Services: make(map[string]Service)}
+		if name := sharedGroup.Name; edited.Name != name && !(name == "" && edited.Name == groupKey) {
+			override.Name = edited.Name
+		}
+		if !slices.Equal(edited.EnvFiles, sharedGroup.EnvFiles) {
+			override.EnvFiles = edited.EnvFiles
+		}
+
+		seen := make(map[string]bool)
+		for serviceKey, sharedService := range sharedGroup.Services {
+			serviceId := ID(root, groupKey, serviceKey)
+			seen[serviceId] = true
+			if svc, exists := edited.Services[serviceId]; exists {
+				if diff := diffService(sharedService.toConfig(root, serviceKey), svc, root); !reflect.DeepEqual(diff, Service{}) {
+					override.Services[serviceKey] = diff
+				}
+			}
+		}
+		// Services added in the launcher only exist in the overrides
+		for serviceId, svc := range edited.Services {
+			if !seen[serviceId] {
+				override.Services[uniqueKey(svc.Name, override.Services, sharedGroup.Services)] = fromConfig(svc, root)
+			}
+		}
+
+		if override.Name != "" || len(override.Env) > 0 || len(override.EnvFiles) > 0 || len(override.Services) > 0 {
+			overrides.Groups[groupKey] = override
+		}
+	}
+
+	localPath := filepath.Join(root, LocalFileName)
+	if len(overrides.Groups) == 0 {
+		if _, err := os.Stat(localPath); os.IsNotExist(err) {
+			return nil
+		}
+	}
+	previous, err := os.ReadFile(localPath)
+	if err != nil {
+		previous = []byte("# Personal overrides of " + FileName + ", written by the launcher. Do not commit.\ngroups:\n")
+	}
+	data, err := config.Marshal(overrides, ".yaml", previous)
+	if err != nil {
+		return err
+	}
+	return config.WriteFileAtomic(localPath, data, 0644)
+}
+
+// readFile parses a project or overrides file
+func readFile(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return File{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// merge applies the overrides in local on top of shared
+func merge(shared, local File) File {
+	merged := File{Groups: make(map[string]Group)}
+	for key, group := range shared.Groups {
+		merged.Groups[key] = group
+	}
+	for key, override := range local.Groups {
+		group := merged.Groups[key]
+		if override.Name != "" {
+			group.Name = override.Name
+		}
+		if override.EnvFiles != nil {
+			group.EnvFiles = override.EnvFiles
+		}
+		group.Env = mergeEnv(group.Env, override.Env)
+
+		services := make(map[string]Service)
+		for serviceKey, svc := range group.Services {
+			services[serviceKey] = svc
+		}
+		for serviceKey, svc := range override.Services {
+			services[serviceKey] = mergeService(services[serviceKey], svc)
+		}
+		group.Services = services
+		merged.Groups[key] = group
+	}
+	return merged
+}
+
+// mergeService applies the fields set in override on top of base
+func mergeService(base, override Service) Service {
+	if override.Name != "" {
+		base.Name = override.Name
+	}
+	if override.Path != "" {
+		base.Path = override.Path
+	}
+	if override.Type != "" {
+		base.Type = override.Type
+	}
+	if override.Ports != nil {
+		base.Ports = override.Ports
+	}
+	if override.NamedPorts != nil {
+		base.NamedPorts = override.NamedPorts
+	}
+	if override.Proxy != nil {
+		base.Proxy = override.Proxy
+	}
+	if override.DependsOn != nil {
+		base.DependsOn = override.DependsOn
+	}
+	if override.EnvFiles != nil {
+		base.EnvFiles = override.EnvFiles
+	}
+	if override.LaunchProfile != "" {
+		base.LaunchProfile = override.LaunchProfile
+	}
+	if override.Tags != nil {
+		base.Tags = override.Tags
+	}
+	if override.PackageManager != "" {
+		base.PackageManager = override.PackageManager
+	}
+	if override.Script != "" {
+		base.Script = override.Script
+	}
+	if override.Args != nil {
+		base.Args = override.Args
+	}
+	if override.ComposeFile != "" {
+		base.ComposeFile = override.ComposeFile
+	}
+	if override.ComposeService != "" {
+		base.ComposeService = override.ComposeService
+	}
+	if override.Command != "" {
+		base.Command = override.Command
+	}
+	base.Env = mergeEnv(base.Env, override.Env)
+	return base
+}
+
+// mergeEnv overlays override on base. An empty value unsets the variable, as in service env.
+func mergeEnv(base, override config.ServiceEnv) config.ServiceEnv {
+	merged := copyEnv(base)
+	for key, value := range override {
+		if value == "" {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// diffEnv returns the variables of edited that differ from base, with "" for removed ones
+func diffEnv(base, edited config.ServiceEnv) config.ServiceEnv {
+	diff := make(config.ServiceEnv)
+	for key, value := range edited {
+		if baseValue, exists := base[key]; !exists || baseValue != value {
+			diff[key] = value
+		}
+	}
+	for key := range base {
+		if _, exists := edited[key]; !exists {
+			diff[key] = ""
+		}
+	}
+	return diff
+}
+
+// diffService returns the fields of edited that differ from base
+func diffService(base, edited config.ServiceConfig, root string) Service {
+	var diff Service
+	if edited.Name != base.Name {
+		diff.Name = edited.Name
+	}
+	if edited.Path != base.Path {
+		diff.Path = relativePath(root, edited.Path)
+	}
+	if edited.Type != base.Type {
+		diff.Type = edited.Type
+	}
+	if !reflect.DeepEqual(edited.Ports, base.Ports) {
+		diff.Ports = edited.Ports
+	}
+	if !reflect.DeepEqual(edited.NamedPorts, base.NamedPorts) {
+		diff.NamedPorts = edited.NamedPorts
+	}
+	if !reflect.DeepEqual(edited.Proxy, base.Proxy) {
+		diff.Proxy = edited.Proxy
+		if diff.Proxy == nil {
+			diff.Proxy = &config.ProxyRoute{}
+		}
+	}
+	if !reflect.DeepEqual(edited.DependsOn, base.DependsOn) {
+		diff.DependsOn = edited.DependsOn
+	}
+	if !slices.Equal(edited.EnvFiles, base.EnvFiles) {
+		diff.EnvFiles = edited.EnvFiles
+	}
+	if edited.LaunchProfile != base.LaunchProfile {
+		diff.LaunchProfile = edited.LaunchProfile
+	}
+	if !slices.Equal(edited.Tags, base.Tags) {
+		diff.Tags = edited.Tags
+	}
+	if edited.PackageManager != base.PackageManager {
+		diff.PackageManager = edited.PackageManager
+	}
+	if edited.Script != base.Script {
+		diff.Script = edited.Script
+	}
+	if !slices.Equal(edited.Args, base.Args) {
+		diff.Args = edited.Args
+	}
+	if edited.ComposeFile != base.ComposeFile {
+		diff.ComposeFile = edited.ComposeFile
+	}
+	if edited.ComposeService != base.ComposeService {
+		diff.ComposeService = edited.ComposeService
+	}
+	if edited.Command != base.Command {
+		diff.Command = edited.Command
+	}
+	if env := diffEnv(base.Env, edited.Env); len(env) > 0 {
+		diff.Env = env
+	}
+	return diff
+}
+
+// toConfig converts a project service into a service config with an absolute path
+func (s Service) toConfig(root string, key string) config.ServiceConfig {
+	name := s.Name
+	if name == "" {
+		name = key
+	}
+	path := s.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, filepath.FromSlash(path))
+	}
+	serviceType := s.Type
+	if serviceType == "" {
+		serviceType = "dotnet"
+	}
+	var proxy *config.ProxyRoute
+	if s.Proxy != nil && *s.Proxy != (config.ProxyRoute{}) {
+		proxy = s.Proxy
+	}
+	return config.ServiceConfig{
+		Name:           name,
+		Path:           path,
+		Env:            copyEnv(s.Env),
+		Type:           serviceType,
+		Ports:          s.Ports,
+		NamedPorts:     s.NamedPorts,
+		Proxy:          proxy,
+		DependsOn:      s.DependsOn,
+		EnvFiles:       s.EnvFiles,
+		LaunchProfile:  s.LaunchProfile,
+		Tags:           s.Tags,
+		PackageManager: s.PackageManager,
+		Script:         s.Script,
+		Args:           s.Args,
+		ComposeFile:    s.ComposeFile,
+		ComposeService: s.ComposeService,
+		Command:        s.Command,
+	}
+}
+
+// fromConfig converts a service config into a project service with a relative path
+func fromConfig(svc config.ServiceConfig, root string) Service {
+	return Service{
+		Name:           svc.Name,
+		Path:           relativePath(root, svc.Path),
+		Type:           svc.Type,
+		Env:            svc.Env,
+		Ports:          svc.Ports,
+		NamedPorts:     svc.NamedPorts,
+		Proxy:          svc.Proxy,
+		DependsOn:      svc.DependsOn,
+		EnvFiles:       svc.EnvFiles,
+		LaunchProfile:  svc.LaunchProfile,
+		Tags:           svc.Tags,
+		PackageManager: svc.PackageManager,
+		Script:         svc.Script,
+		Args:           svc.Args,
+		ComposeFile:    svc.ComposeFile,
+		ComposeService: svc.ComposeService,
+		Command:        svc.Command,
+	}
+}
+
+// relativePath makes path relative to root when it lies inside it
+func relativePath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+var nonKeyChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// uniqueKey derives a service key from name that is not used yet
+func uniqueKey(name string, used ...map[string]Service) string {
+	base := strings.Trim(nonKeyChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if base == "" {
+		base = "service"
+	}
+	key := base
+	for i := 2; ; i++ {
+		taken := false
+		for _, services := range used {
+			if _, exists := services[key]; exists {
+				taken = true
+			}
+		}
+		if !taken {
+			return key
+		}
+		key = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// copyEnv returns a copy of env that is never nil
+func copyEnv(env config.ServiceEnv) config.ServiceEnv {
+	result := make(config.ServiceEnv)
+	for key, value := range env {
+		result[key] = value
+	}
+	return result
+}
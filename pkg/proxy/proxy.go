@@ -0,0 +1,352 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAddress is where the proxy listens when the config does not say otherwise
+const DefaultAddress = "127.0.0.1:8080"
+
+// Route maps a stable hostname and/or path prefix to a service
+type Route struct {
+	ServiceID   string
+	ServiceName string
+	Host        string // e.g. "api.localhost", empty matches any host
+	PathPrefix  string // e.g. "/api", empty matches every path
+	StripPrefix bool   // Remove PathPrefix before forwarding
+	Record      bool   // Capture exchanges through the recorder
+}
+
+// Target describes where a service can currently be reached
+type Target struct {
+	URL    string // Detected service URL, empty while unknown
+	Ready  bool   // Whether the service is running
+	Status string // Status shown on the placeholder page
+}
+
+// TargetFunc resolves the current target of a service
+type TargetFunc func(serviceId string) Target
+
+// Recorder captures exchanges of routes with recording enabled
+type Recorder interface {
+	Capture(serviceId string, w http.ResponseWriter, r *http.Request, next http.Handler)
+}
+
+// DefaultTLSAddress is where the proxy terminates HTTPS when enabled without an address
+const DefaultTLSAddress = "127.0.0.1:8443"
+
+// endpoint is one listener of the proxy
+type endpoint struct {
+	server   *http.Server
+	address  string
+	listener net.Listener
+}
+
+// Server is a reverse proxy in front of the launcher's services
+type Server struct {
+	mu        sync.RWMutex
+	routes    []Route
+	target    TargetFunc
+	plain     *endpoint
+	secure    *endpoint
+	transport http.RoundTripper
+	recorder  Recorder
+}
+
+// NewServer creates a proxy that resolves service targets through target
+func NewServer(target TargetFunc) *Server {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Upstreams are local dev servers, typically with self-signed certificates
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &Server{target: target, transport: transport}
+}
+
+// SetRoutes replaces the routing table
+func (s *Server) SetRoutes(routes []Route) {
+	sorted := append([]Route{}, routes...)
+	// Host specific routes win over catch-all ones, then longer prefixes over shorter ones
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if (sorted[i].Host != "") != (sorted[j].Host != "") {
+			return sorted[i].Host != ""
+		}
+		return len(sorted[i].PathPrefix) > len(sorted[j].PathPrefix)
+	})
+	s.mu.Lock()
+	s.routes = sorted
+	s.mu.Unlock()
+}
+
+// SetRecorder sets where recorded exchanges are captured
+func (s *Server) SetRecorder(recorder Recorder) {
+	s.mu.Lock()
+	s.recorder = recorder
+	s.mu.Unlock()
+}
+
+// Routes returns the current routing table
+func (s *Server) Routes() []Route {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Route{}, s.routes...)
+}
+
+// Address returns the address the HTTP listener is bound to, empty when stopped
+func (s *Server) Address() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.plain.boundAddress()
+}
+
+// TLSAddress returns the address the HTTPS listener is bound to, empty when stopped
+func (s *Server) TLSAddress() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.secure.boundAddress()
+}
+
+// Start serves plain HTTP on address, restarting the listener if the address changed
+func (s *Server) Start(address string) error {
+	if address == "" {
+		address = DefaultAddress
+	}
+	return s.listen(&s.plain, address, nil)
+}
+
+// StartTLS terminates HTTPS on address using tlsConfig for certificates
+func (s *Server) StartTLS(address string, tlsConfig *tls.Config) error {
+	if address == "" {
+		address = DefaultTLSAddress
+	}
+	return s.listen(&s.secure, address, tlsConfig)
+}
+
+// Stop shuts down all listeners
+func (s *Server) Stop() error {
+	if err := s.StopTLS(); err != nil {
+		return err
+	}
+	return s.shutdown(&s.plain)
+}
+
+// StopTLS shuts down the HTTPS listener only
+func (s *Server) StopTLS() error {
+	return s.shutdown(&s.secure)
+}
+
+// listen (re)starts the endpoint in slot unless it already serves address
+func (s *Server) listen(slot **endpoint, address string, tlsConfig *tls.Config) error {
+	s.mu.RLock()
+	running := *slot != nil && (*slot).address == address
+	s.mu.RUnlock()
+	if running {
+		return nil
+	}
+	if err := s.shutdown(slot); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("proxy cannot listen on %s: %w", address, err)
+	}
+	server := &http.Server{Handler: s, TLSConfig: tlsConfig}
+
+	s.mu.Lock()
+	*slot = &endpoint{server: server, address: address, listener: listener}
+	s.mu.Unlock()
+
+	if tlsConfig != nil {
+		go server.ServeTLS(listener, "", "")
+	} else {
+		go server.Serve(listener)
+	}
+	return nil
+}
+
+// shutdown stops the endpoint in slot if it is running
+func (s *Server) shutdown(slot **endpoint) error {
+	s.mu.Lock()
+	ep := *slot
+	*slot = nil
+	s.mu.Unlock()
+	if ep == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := ep.server.Shutdown(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return nil
+}
+
+// boundAddress returns the actual listen address of the endpoint, empty when nil
+func (ep *endpoint) boundAddress() string {
+	if ep == nil {
+		return ""
+	}
+	return ep.listener.Addr().String()
+}
+
+// Match returns the route for a request host and path
+func (s *Server) Match(host, path string) (Route, bool) {
+	host = strings.ToLower(stripPort(host))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, route := range s.routes {
+		if route.Host != "" && !strings.EqualFold(route.Host, host) {
+			continue
+		}
+		if route.PathPrefix != "" && !hasPathPrefix(path, route.PathPrefix) {
+			continue
+		}
+		return route, true
+	}
+	return Route{}, false
+}
+
+// ServeHTTP forwards the request to the matching service
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, ok := s.Match(r.Host, r.URL.Path)
+	if !ok {
+		http.Error(w, fmt.Sprintf("No service is mapped to %s%s", r.Host, r.URL.Path), http.StatusNotFound)
+		return
+	}
+
+	s.mu.RLock()
+	recorder := s.recorder
+	s.mu.RUnlock()
+	if route.Record && recorder != nil {
+		recorder.Capture(route.ServiceID, w, r, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.forward(w, r, route)
+		}))
+		return
+	}
+	s.forward(w, r, route)
+}
+
+// forward proxies the request to the service of route
+func (s *Server) forward(w http.ResponseWriter, r *http.Request, route Route) {
+	target := s.target(route.ServiceID)
+	if !target.Ready || target.URL == "" {
+		writePlaceholder(w, route, target.Status)
+		return
+	}
+	upstream, err := url.Parse(LoopbackURL(target.URL))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid service URL %q: %v", target.URL, err), http.StatusBadGateway)
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if route.StripPrefix && route.PathPrefix != "" {
+				pr.Out.URL.Path = "/" + strings.TrimLeft(strings.TrimPrefix(pr.In.URL.Path, strings.TrimRight(route.PathPrefix, "/")), "/")
+				pr.Out.URL.RawPath = ""
+			}
+			pr.SetURL(upstream)
+			pr.SetXForwarded()
+			// Keep the stable hostname so cookies and redirect URIs are built against it
+			pr.Out.Host = pr.In.Host
+		},
+		Transport: s.transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			// The service is up but not accepting connections yet
+			writePlaceholder(w, route, "starting")
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// LoopbackURL rewrites wildcard listen addresses such as "http://[::]:5000"
+// or "http://0.0.0.0:5000" into a dialable localhost URL
+func LoopbackURL(rawURL string) string {
+	for _, wildcard := range []string{"://[::]", "://0.0.0.0", "://*", "://+"} {
+		if strings.Contains(rawURL, wildcard) {
+			return strings.Replace(rawURL, wildcard, "://localhost", 1)
+		}
+	}
+	return rawURL
+}
+
+// PublicURL returns the stable URL of a route on a proxy listening at address
+func PublicURL(route Route, address string, scheme string) string {
+	host := route.Host
+	bindHost, port, err := net.SplitHostPort(address)
+	if host == "" {
+		host = "localhost"
+		if err == nil && bindHost != "" && bindHost != "0.0.0.0" && bindHost != "::" {
+			host = bindHost
+		}
+	}
+	if err == nil && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host = net.JoinHostPort(host, port)
+	}
+	return scheme + "://" + host + route.PathPrefix
+}
+
+// stripPort removes the port from a Host header value
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// hasPathPrefix reports whether path lies under prefix on a segment boundary
+func hasPathPrefix(path, prefix string) bool {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+var placeholderTemplate = template.Must(template.New("placeholder").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="2">
+<title>{{.Name}} is starting</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #1b2636; color: #e5e7eb; display: flex; align-items: center; justify-content: center; height: 100vh; margin: 0; }
+div { text-align: center; }
+h1 { font-size: 1.5rem; margin-bottom: .5rem; }
+p { color: #9ca3af; }
+</style>
+</head>
+<body>
+<div>
+<h1>{{.Name}} is not ready yet</h1>
+<p>Status: {{.Status}}. This page reloads automatically.</p>
+</div>
+</body>
+</html>
+`))
+
+// writePlaceholder renders the page shown while the target service is not running
+func writePlaceholder(w http.ResponseWriter, route Route, status string) {
+	if status == "" {
+		status = "unknown"
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Retry-After", "2")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	placeholderTemplate.Execute(w, map[string]string{
+		"Name":   route.ServiceName,
+		"Status": status,
+	})
+}
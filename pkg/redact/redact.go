@@ -0,0 +1,179 @@
+package redact
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Mask replaces redacted values in logs and exports
+const Mask = "******"
+
+// minMaskLength keeps very short values from masking unrelated text
+const minMaskLength = 4
+
+// DefaultSensitiveEnv are the env var names whose values are masked unless
+// the defaults are turned off. Names are matched case-insensitively.
+var DefaultSensitiveEnv = []string{
+	"*PASSWORD*",
+	"*PASSWD*",
+	"*SECRET*",
+	"*TOKEN*",
+	"*API_KEY*",
+	"*APIKEY*",
+	"*PRIVATE_KEY*",
+	"CONNECTIONSTRINGS__*",
+	"*CONNECTION_STRING*",
+}
+
+// DefaultPatterns mask JWTs, bearer tokens, passwords in URLs and in
+// connection strings unless the defaults are turned off. When a pattern has
+// a capture group only the first group is masked.
+var DefaultPatterns = []string{
+	`eyJ[A-Za-z0-9_-]{4,}\.eyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]+`,
+	`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{8,}=*)`,
+	`[A-Za-z][A-Za-z0-9+.-]*://[^:/@\s]*:([^@/\s]+)@`,
+	`(?i)\b(?:password|pwd)\s*=\s*([^;\s"']+)`,
+}
+
+// Policy decides what is masked in logs and exports: values of sensitive env
+// vars, passed by the caller, and matches of the patterns. It is shared by
+// the services and can be changed at runtime.
+type Policy struct {
+	mu        sync.RWMutex
+	sensitive []string
+	patterns  []*regexp.Regexp
+}
+
+// NewPolicy creates a policy, see SetRules
+func NewPolicy(sensitiveEnv, patterns []string) *Policy {
+	p := &Policy{}
+	p.SetRules(sensitiveEnv, patterns)
+	return p
+}
+
+// SetRules replaces the sensitive env var names, which may use * and ?
+// wildcards, and the patterns. Invalid patterns are skipped, configs are
+// validated before they get here.
+func (p *Policy) SetRules(sensitiveEnv, patterns []string) {
+	sensitive := make([]string, 0, len(sensitiveEnv))
+	for _, name := range sensitiveEnv {
+		sensitive = append(sensitive, strings.ToUpper(name))
+	}
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			compiled = append(compiled, re)
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sensitive = sensitive
+	p.patterns = compiled
+}
+
+// Sensitive reports whether the value of an env var is masked
+func (p *Policy) Sensitive(key string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key = strings.ToUpper(key)
+	for _, name := range p.sensitive {
+		if matched, _ := path.Match(name, key); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// SensitiveValues returns the values of the sensitive vars in env
+func (p *Policy) SensitiveValues(env map[string]string) []string {
+	var values []string
+	for key, value := range env {
+		if p.Sensitive(key) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Redact masks the values and the pattern matches in text
+func (p *Policy) Redact(text string, values []string) string {
+	return p.maskPatterns(MaskValues(text, values))
+}
+
+// RedactJSON masks the values, also in escaped form, and the pattern
+// matches in JSON data
+func (p *Policy) RedactJSON(data []byte, values []string) []byte {
+	return []byte(p.maskPatterns(string(MaskJSON(data, values))))
+}
+
+func (p *Policy) maskPatterns(text string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, re := range p.patterns {
+		text = maskMatches(re, text)
+	}
+	return text
+}
+
+// maskMatches replaces the first group of each match, or the whole match
+// when the pattern has no group
+func maskMatches(re *regexp.Regexp, text string) string {
+	matches := re.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if end <= start {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(Mask)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// MaskValues replaces every occurrence of the values in text
+func MaskValues(text string, values []string) string {
+	for _, value := range maskOrder(values) {
+		text = strings.ReplaceAll(text, value, Mask)
+	}
+	return text
+}
+
+// MaskJSON replaces the values in JSON data, in plain and in escaped form
+func MaskJSON(data []byte, values []string) []byte {
+	forms := slices.Clone(values)
+	for _, value := range values {
+		if encoded, err := json.Marshal(value); err == nil {
+			if escaped := string(encoded[1 : len(encoded)-1]); escaped != value {
+				forms = append(forms, escaped)
+			}
+		}
+	}
+	return []byte(MaskValues(string(data), forms))
+}
+
+// maskOrder returns the values long enough to mask without duplicates,
+// longest first so a value that contains another is masked as a whole
+func maskOrder(values []string) []string {
+	var ordered []string
+	for _, value := range values {
+		if len(value) >= minMaskLength && !slices.Contains(ordered, value) {
+			ordered = append(ordered, value)
+		}
+	}
+	slices.SortStableFunc(ordered, func(a, b string) int { return len(b) - len(a) })
+	return ordered
+}
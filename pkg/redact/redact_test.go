@@ -0,0 +1,46 @@
+package redact
+
+import "testing"
+
+func TestMaskValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		values []string
+		want   string
+	}{
+		{name: "single value", text: "pass=hunter22;", values: []string{"hunter22"}, want: "pass=******;"},
+		{name: "prefix given first", text: "key abcdefgh", values: []string{"abcd", "abcdefgh"}, want: "key ******"},
+		{name: "substring given first", text: "key xxabcdxx and abcd", values: []string{"abcd", "xxabcdxx"}, want: "key ****** and ******"},
+		{name: "duplicates and empty values", text: "a secret", values: []string{"", "secret", "secret"}, want: "a ******"},
+		{name: "too short to mask", text: "abc", values: []string{"abc"}, want: "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaskValues(tt.text, tt.values); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		values []string
+		want   string
+	}{
+		{name: "escaped value", data: `{"v":"a\"quoted\""}`, values: []string{`a"quoted"`}, want: `{"v":"******"}`},
+		{name: "overlapping values", data: `{"a":"abcd","b":"abcd\"efgh"}`, values: []string{"abcd", `abcd"efgh`}, want: `{"a":"******","b":"******"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(MaskJSON([]byte(tt.data), tt.values)); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
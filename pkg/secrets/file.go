@@ -0,0 +1,171 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	keyFileName     = "secrets.key"
+	secretsFileName = "secrets.enc"
+)
+
+// fileBackend keeps secrets in an AES-GCM encrypted file. The key sits next
+// to it in the same directory, so this is only obfuscation: it keeps the
+// values out of the config file and from casual reading, but anyone who can
+// read the directory, or a copy or backup of it, can decrypt them.
+type fileBackend struct {
+	dir string
+}
+
+func newFileBackend(dir string) *fileBackend {
+	return &fileBackend{dir: dir}
+}
+
+func (f *fileBackend) get(name string) (string, error) {
+	values, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (f *fileBackend) set(name, value string) error {
+	values, err := f.load()
+	if err != nil {
+		return err
+	}
+	values[name] = value
+	return f.save(values)
+}
+
+func (f *fileBackend) delete(name string) error {
+	values, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[name]; !ok {
+		return ErrNotFound
+	}
+	delete(values, name)
+	return f.save(values)
+}
+
+func (f *fileBackend) list() ([]string, error) {
+	values, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// aead returns the cipher, creating the key on first use
+func (f *fileBackend) aead() (cipher.AEAD, error) {
+	keyPath := filepath.Join(f.dir, keyFileName)
+	key, err := os.ReadFile(keyPath)
+	if os.IsNotExist(err) {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(f.dir, 0700); err != nil {
+			return nil, err
+		}
+		if err := writePrivate(keyPath, key); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%s is not a valid key", keyPath)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// load decrypts the secrets file, a missing file holds no secrets
+func (f *fileBackend) load() (map[string]string, error) {
+	path := filepath.Join(f.dir, secretsFileName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	aead, err := f.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("%s is damaged", path)
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt %s, was %s replaced? %w", path, keyFileName, err)
+	}
+	values := make(map[string]string)
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return nil, fmt.Errorf("%s is damaged: %w", path, err)
+	}
+	return values, nil
+}
+
+// save encrypts the secrets with a fresh nonce
+func (f *fileBackend) save(values map[string]string) error {
+	aead, err := f.aead()
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	return writePrivate(filepath.Join(f.dir, secretsFileName), aead.Seal(nonce, nonce, plain, nil))
+}
+
+// writePrivate replaces a file readable only by the user
+func writePrivate(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
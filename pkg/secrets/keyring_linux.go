@@ -0,0 +1,190 @@
+package secrets
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Secret Service API, see https://specifications.freedesktop.org/secret-service/
+const (
+	ssName           = "org.freedesktop.secrets"
+	ssPath           = dbus.ObjectPath("/org/freedesktop/secrets")
+	ssService        = "org.freedesktop.Secret.Service"
+	ssCollection     = "org.freedesktop.Secret.Collection"
+	ssItem           = "org.freedesktop.Secret.Item"
+	ssPrompt         = "org.freedesktop.Secret.Prompt"
+	ssNoPrompt       = dbus.ObjectPath("/")
+	ssPromptTimeout  = 2 * time.Minute
+	applicationLabel = "wails-launcher"
+)
+
+// ssSecret is the Secret struct of the Secret Service API
+type ssSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// keyring keeps secrets in the default collection of the Secret Service,
+// e.g. GNOME Keyring or KWallet. Items are found by their attributes.
+type keyring struct {
+	conn       *dbus.Conn
+	session    dbus.ObjectPath
+	collection dbus.ObjectPath
+}
+
+// newKeyring connects to the Secret Service on the session bus
+func newKeyring() (backend, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, err
+	}
+	service := conn.Object(ssName, ssPath)
+
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	if err := service.Call(ssService+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &session); err != nil {
+		return nil, err
+	}
+	var collection dbus.ObjectPath
+	if err := service.Call(ssService+".ReadAlias", 0, "default").Store(&collection); err != nil {
+		return nil, err
+	}
+	if collection == ssNoPrompt {
+		return nil, fmt.Errorf("the Secret Service has no default collection")
+	}
+	return &keyring{conn: conn, session: session, collection: collection}, nil
+}
+
+func attributes(name string) map[string]string {
+	attrs := map[string]string{"application": applicationLabel}
+	if name != "" {
+		attrs["name"] = name
+	}
+	return attrs
+}
+
+// search returns the unlocked items matching the attributes, unlocking locked ones
+func (k *keyring) search(attrs map[string]string) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	err := k.conn.Object(ssName, ssPath).Call(ssService+".SearchItems", 0, attrs).Store(&unlocked, &locked)
+	if err != nil {
+		return nil, err
+	}
+	if len(locked) == 0 {
+		return unlocked, nil
+	}
+	var prompt dbus.ObjectPath
+	var now []dbus.ObjectPath
+	if err := k.conn.Object(ssName, ssPath).Call(ssService+".Unlock", 0, locked).Store(&now, &prompt); err != nil {
+		return nil, err
+	}
+	if err := k.prompt(prompt); err != nil {
+		return nil, err
+	}
+	return append(unlocked, locked...), nil
+}
+
+// prompt shows a Secret Service prompt, e.g. to unlock the keyring, and waits for it
+func (k *keyring) prompt(path dbus.ObjectPath) error {
+	if path == ssNoPrompt || path == "" {
+		return nil
+	}
+	signals := make(chan *dbus.Signal, 4)
+	k.conn.Signal(signals)
+	defer k.conn.RemoveSignal(signals)
+	match := []dbus.MatchOption{dbus.WithMatchObjectPath(path), dbus.WithMatchInterface(ssPrompt), dbus.WithMatchMember("Completed")}
+	if err := k.conn.AddMatchSignal(match...); err != nil {
+		return err
+	}
+	defer k.conn.RemoveMatchSignal(match...)
+
+	if err := k.conn.Object(ssName, path).Call(ssPrompt+".Prompt", 0, "").Err; err != nil {
+		return err
+	}
+	timeout := time.After(ssPromptTimeout)
+	for {
+		select {
+		case signal := <-signals:
+			if signal.Path != path || signal.Name != ssPrompt+".Completed" {
+				continue
+			}
+			if dismissed, _ := signal.Body[0].(bool); dismissed {
+				return fmt.Errorf("the keyring prompt was dismissed")
+			}
+			return nil
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for the keyring prompt")
+		}
+	}
+}
+
+func (k *keyring) get(name string) (string, error) {
+	items, err := k.search(attributes(name))
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", ErrNotFound
+	}
+	var secret ssSecret
+	if err := k.conn.Object(ssName, items[0]).Call(ssItem+".GetSecret", 0, k.session).Store(&secret); err != nil {
+		return "", err
+	}
+	return string(secret.Value), nil
+}
+
+func (k *keyring) set(name, value string) error {
+	properties := map[string]dbus.Variant{
+		ssItem + ".Label":      dbus.MakeVariant(applicationLabel + ": " + name),
+		ssItem + ".Attributes": dbus.MakeVariant(attributes(name)),
+	}
+	secret := ssSecret{Session: k.session, Value: []byte(value), ContentType: "text/plain; charset=utf8"}
+	var item, prompt dbus.ObjectPath
+	err := k.conn.Object(ssName, k.collection).Call(ssCollection+".CreateItem", 0, properties, secret, true).Store(&item, &prompt)
+	if err != nil {
+		return err
+	}
+	return k.prompt(prompt)
+}
+
+func (k *keyring) delete(name string) error {
+	items, err := k.search(attributes(name))
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return ErrNotFound
+	}
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		if err := k.conn.Object(ssName, item).Call(ssItem+".Delete", 0).Store(&prompt); err != nil {
+			return err
+		}
+		if err := k.prompt(prompt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *keyring) list() ([]string, error) {
+	items, err := k.search(attributes(""))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, item := range items {
+		variant, err := k.conn.Object(ssName, item).GetProperty(ssItem + ".Attributes")
+		if err != nil {
+			return nil, err
+		}
+		if attrs, ok := variant.Value().(map[string]string); ok && attrs["name"] != "" {
+			names = append(names, attrs["name"])
+		}
+	}
+	return names, nil
+}
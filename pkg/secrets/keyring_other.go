@@ -0,0 +1,10 @@
+//go:build !linux
+
+package secrets
+
+import "fmt"
+
+// newKeyring reports that the Secret Service is only used on Linux
+func newKeyring() (backend, error) {
+	return nil, fmt.Errorf("the Secret Service is only supported on Linux")
+}
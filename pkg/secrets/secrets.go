@@ -0,0 +1,125 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned for secrets that are not stored
+var ErrNotFound = errors.New("secret not found")
+
+// RefPrefix starts the name of a secret in an env reference, ${secret:<name>}
+const RefPrefix = "secret:"
+
+// Ref returns the env value referring to a secret
+func Ref(name string) string {
+	return "${" + RefPrefix + name + "}"
+}
+
+// ValidateName checks that a name can be used in a reference
+func ValidateName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return fmt.Errorf("secret names cannot be empty")
+	case strings.ContainsAny(name, "{}$"), strings.Contains(name, ":-"):
+		return fmt.Errorf("secret name %q cannot contain {, }, $ or :-", name)
+	}
+	return nil
+}
+
+// backend persists secrets
+type backend interface {
+	get(name string) (string, error)
+	set(name, value string) error
+	delete(name string) error
+	list() ([]string, error)
+}
+
+// Store keeps secret values outside the config, in the desktop keyring when
+// one is available and in an obfuscated file otherwise. Values are cached
+// after the first read.
+type Store struct {
+	backend backend
+	kind    string
+	mu      sync.Mutex
+	cache   map[string]string
+}
+
+// Backends reported by Store.Backend
+const (
+	BackendKeyring = "keyring"
+	BackendFile    = "file"
+)
+
+// Open connects to the keyring, falling back to an obfuscated file in dir.
+// Setting LAUNCHER_SECRETS=file skips the keyring. The error tells why the
+// keyring is not used, the store works either way.
+func Open(dir string) (*Store, error) {
+	file := &Store{backend: newFileBackend(dir), kind: BackendFile, cache: make(map[string]string)}
+	if os.Getenv("LAUNCHER_SECRETS") == BackendFile {
+		return file, nil
+	}
+	keyring, err := newKeyring()
+	if err != nil {
+		return file, fmt.Errorf("keyring not available, secrets are kept in an obfuscated file next to the config, readable by anyone with access to it: %w", err)
+	}
+	return &Store{backend: keyring, kind: BackendKeyring, cache: make(map[string]string)}, nil
+}
+
+// Backend returns where the secrets are kept
+func (s *Store) Backend() string {
+	return s.kind
+}
+
+// Get returns the value of a secret
+func (s *Store) Get(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if value, ok := s.cache[name]; ok {
+		return value, nil
+	}
+	value, err := s.backend.get(name)
+	if err != nil {
+		return "", err
+	}
+	s.cache[name] = value
+	return value, nil
+}
+
+// Set stores a secret, replacing an existing one of the same name
+func (s *Store) Set(name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.backend.set(name, value); err != nil {
+		return err
+	}
+	s.cache[name] = value
+	return nil
+}
+
+// Delete removes a secret
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, name)
+	return s.backend.delete(name)
+}
+
+// List returns the names of the stored secrets, sorted
+func (s *Store) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names, err := s.backend.list()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
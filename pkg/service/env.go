@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"wails-launcher/pkg/interpolate"
+	"wails-launcher/pkg/process"
+	"wails-launcher/pkg/secrets"
+)
+
+// envProblem is logged when the service starts
+type envProblem struct {
+	level   process.LogLevel
+	message string
+}
+
+// serviceRefRegex matches service:<name>.url and service:<name>.port, optionally
+// followed by a port name
+var serviceRefRegex = regexp.MustCompile(`^service:(.+)\.(url|port)(?:\.([A-Za-z0-9_-]+))?$`)
+
+// expandEnv resolves ${...} references in the merged environment against the
+// environment itself, the host environment, the group environment and other
+// services. Callers must hold the lock.
+func (s *Service) expandEnv(env process.ServiceEnv) []envProblem {
+	errs := interpolate.Expand(env, interpolate.Scope{
+		Lookup:  os.LookupEnv,
+		Resolve: s.resolveRef,
+	})
+	problems := make([]envProblem, 0, len(errs))
+	for _, err := range errs {
+		problems = append(problems, envProblem{process.Err, fmt.Sprintf("Cannot expand %v", err)})
+	}
+	return problems
+}
+
+// resolveRef resolves ${group.env.<name>}, ${secret:<name>} and
+// ${service:<name>.url|port[.<port name>]}. Callers must hold the lock.
+func (s *Service) resolveRef(ref string) (string, error) {
+	if name, ok := strings.CutPrefix(ref, secrets.RefPrefix); ok {
+		if s.secrets == nil {
+			return "", fmt.Errorf("secrets are not available")
+		}
+		value, err := s.secrets.Get(name)
+		if err != nil {
+			return "", err
+		}
+		s.secretValues = append(s.secretValues, value)
+		// Resolved values are expanded in turn, keep the secret literal
+		return strings.ReplaceAll(value, "${", "$${"), nil
+	}
+	if name, ok := strings.CutPrefix(ref, "group.env."); ok {
+		value, ok := s.InheritedEnv[name]
+		if !ok {
+			return "", fmt.Errorf("the group does not define %s", name)
+		}
+		return value, nil
+	}
+
+	m := serviceRefRegex.FindStringSubmatch(ref)
+	if m == nil {
+		return "", fmt.Errorf("unknown reference, expected service:<name>.url, service:<name>.port, group.env.<name> or secret:<name>")
+	}
+	name, kind, portName := m[1], m[2], m[3]
+	if kind == "url" && portName == "" {
+		return s.ports.URL(s.ID, name)
+	}
+	port, err := s.ports.Lookup(s.ID, name, portName)
+	if err != nil {
+		return "", err
+	}
+	if kind == "url" {
+		return portURL(portName, port), nil
+	}
+	return strconv.Itoa(port), nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/launchsettings"
+	"wails-launcher/pkg/process"
+)
+
+// launchProfile returns the launch profile a dotnet service runs with, the
+// selected one or the one dotnet run picks by default. Nil when the project
+// has none.
+func launchProfile(cfg config.ServiceConfig) (*launchsettings.NamedProfile, error) {
+	if cfg.Type != "" && cfg.Type != "dotnet" {
+		return nil, nil
+	}
+	settings, err := launchsettings.Load(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.LaunchProfile == "" {
+		return settings.DefaultProfile(), nil
+	}
+	profile := settings.Profile(cfg.LaunchProfile)
+	if profile == nil {
+		return nil, fmt.Errorf("%s has no profile %q", launchsettings.FilePath(cfg.Path), cfg.LaunchProfile)
+	}
+	return profile, nil
+}
+
+// LaunchProfileEnv returns the name of the launch profile of a dotnet service
+// and the variables it sets, its applicationUrl as ASPNETCORE_URLS
+func LaunchProfileEnv(cfg config.ServiceConfig) (string, config.ServiceEnv, error) {
+	profile, err := launchProfile(cfg)
+	if err != nil || profile == nil {
+		return "", nil, err
+	}
+	return profile.Name, profile.Profile.Env(), nil
+}
+
+// launchArgs returns the dotnet run arguments for the launch profile. dotnet
+// run lets the profile's variables win over the environment it is started
+// with, so when the service overrides one of them the launcher applies the
+// profile itself and sets DOTNET_LAUNCH_PROFILE like dotnet run would.
+func launchArgs(profile *launchsettings.NamedProfile, env process.ServiceEnv) ([]string, []envProblem) {
+	if profile == nil {
+		return nil, nil
+	}
+	var overridden []string
+	for key, value := range profile.Profile.Env() {
+		if env[key] != value {
+			overridden = append(overridden, key)
+		}
+	}
+	sort.Strings(overridden)
+
+	var args []string
+	var problems []envProblem
+	if len(overridden) == 0 {
+		args = []string{"--launch-profile", profile.Name}
+	} else {
+		args = []string{"--no-launch-profile"}
+		env["DOTNET_LAUNCH_PROFILE"] = profile.Name
+		problems = append(problems, envProblem{process.Inf, fmt.Sprintf(
+			"Launch profile %s is applied by the launcher because the service overrides %s",
+			profile.Name, strings.Join(overridden, ", "))})
+	}
+	if profileArgs := profile.Profile.Args(); len(profileArgs) > 0 {
+		args = append(append(args, "--"), profileArgs...)
+	}
+	return args, problems
+}
+
+// withExtraArgs appends the service's extra arguments to the run arguments.
+// dotnet run passes what follows -- to the program; the npm service adds the
+// -- itself, compose services pass them to compose up and command services
+// to the command.
+func withExtraArgs(cfg config.ServiceConfig, args []string) []string {
+	if cfg.Type == "npm" || cfg.Type == "compose" || cfg.Type == "command" || len(cfg.Args) == 0 {
+		return append(args, cfg.Args...)
+	}
+	if !slices.Contains(args, "--") {
+		args = append(args, "--")
+	}
+	return append(args, cfg.Args...)
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/workspace"
+)
+
+// npmScripts returns how an npm service runs: the package manager, detected
+// from the lock file unless set, the script Start runs, the configured one
+// or the first of the dev scripts package.json has, and the script
+// StartWithoutBuild runs, start when package.json has it
+func npmScripts(cfg config.ServiceConfig) (manager, script, startScript string) {
+	manager = cfg.PackageManager
+	if manager == "" {
+		manager = workspace.DetectPackageManager(cfg.Path)
+	}
+	script = cfg.Script
+	pkg, err := workspace.ReadPackage(cfg.Path)
+	if err != nil {
+		return manager, script, ""
+	}
+	if script == "" {
+		script = pkg.Script
+	}
+	if _, ok := pkg.Scripts["start"]; ok || script == "" {
+		return manager, script, "start"
+	}
+	// Without a start script, starting without a build runs the dev script too
+	return manager, script, script
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"wails-launcher/pkg/compose"
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/portcheck"
+	"wails-launcher/pkg/process"
+)
+
+// ErrPortConflict is returned by Start when a port the service needs is taken
+var ErrPortConflict = errors.New("port conflict")
+
+// Port conflict resolutions offered to the user
+const (
+	PortConflictKill     = "kill"
+	PortConflictReassign = "reassign"
+	PortConflictAbort    = "abort"
+)
+
+// portNames returns the names of the declared ports
+func portNames(specs []config.PortSpec) []string {
+	names := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		names = append(names, spec.Name)
+	}
+	return names
+}
+
+// envName turns a port name into an environment variable suffix, "grpc-web" -> "GRPC_WEB"
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// portURL builds the listen URL for an allocated port, ports named like "https" get TLS
+func portURL(name string, port int) string {
+	scheme := "http"
+	if strings.Contains(strings.ToLower(name), "https") {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://localhost:%d", scheme, port)
+}
+
+// allocatePorts allocates the declared named ports and pushes the resulting
+// environment to the process manager
+func (s *Service) allocatePorts() error {
+	s.mu.Lock()
+	var allocated map[string]int
+	if len(s.Config.NamedPorts) > 0 {
+		var err error
+		allocated, err = s.ports.Allocate(s.ID)
+		if err != nil {
+			s.mu.Unlock()
+			s.logMessage(process.Err, fmt.Sprintf("Port allocation failed: %v", err))
+			return err
+		}
+	}
+	s.allocatedPorts = allocated
+	env := s.mergedEnv()
+	s.processManager.UpdateConfig(s.Config.Path, env, s.args)
+	problems := s.envProblems
+	specs := s.Config.NamedPorts
+	s.mu.Unlock()
+
+	for _, spec := range specs {
+		s.logMessage(process.Inf, fmt.Sprintf("Allocated port %s: %d", spec.Name, allocated[spec.Name]))
+	}
+	for _, problem := range problems {
+		s.logMessage(problem.level, problem.message)
+	}
+	return nil
+}
+
+// allocatedEnv returns the variables exposing the allocated named ports. Every port
+// is available as PORT_<NAME>; without explicit variables the first port becomes
+// PORT and NUXT_PORT for npm and PORT for commands, dotnet gets all ports as
+// ASPNETCORE_URLS and compose files refer to PORT_<NAME> themselves.
+// Callers must hold the lock.
+func (s *Service) allocatedEnv() process.ServiceEnv {
+	if len(s.allocatedPorts) == 0 {
+		return nil
+	}
+	env := make(process.ServiceEnv)
+	var urls []string
+	for i, spec := range s.Config.NamedPorts {
+		port, ok := s.allocatedPorts[spec.Name]
+		if !ok {
+			continue
+		}
+		value := strconv.Itoa(port)
+		env["PORT_"+envName(spec.Name)] = value
+		for _, name := range spec.Env {
+			env[name] = value
+		}
+		if len(spec.Env) > 0 {
+			continue
+		}
+		switch s.Config.Type {
+		case "npm":
+			if i == 0 {
+				env["PORT"] = value
+				env["NUXT_PORT"] = value
+			}
+		case "command":
+			if i == 0 {
+				env["PORT"] = value
+			}
+		case "compose":
+			// The compose file publishes PORT_<NAME> where it refers to it
+		default:
+			urls = append(urls, portURL(spec.Name, port))
+		}
+	}
+	if len(urls) > 0 {
+		env["ASPNETCORE_URLS"] = strings.Join(urls, ";")
+	}
+	return env
+}
+
+// knownURLs returns the URLs the service is expected to listen on, taken from
+// its allocated ports or the launch profile for dotnet projects,
+// falling back to the last detected URL.
+// Callers must hold the lock.
+func (s *Service) knownURLs() []string {
+	var urls []string
+	if len(s.allocatedPorts) > 0 {
+		for _, spec := range s.Config.NamedPorts {
+			if port, ok := s.allocatedPorts[spec.Name]; ok {
+				urls = append(urls, portURL(spec.Name, port))
+			}
+		}
+		return urls
+	}
+	if profile, err := launchProfile(s.Config); err == nil && profile != nil {
+		urls = append(urls, profile.Profile.ApplicationURLs()...)
+	}
+	if len(urls) == 0 && s.lastURL != "" {
+		urls = append(urls, s.lastURL)
+	}
+	return urls
+}
+
+// knownPorts returns the ports the service will bind, with reassignments applied.
+// Callers must hold the lock.
+func (s *Service) knownPorts() []int {
+	ports := append([]int{}, s.Config.Ports...)
+	for _, u := range s.knownURLs() {
+		if port, ok := portcheck.PortFromURL(u); ok {
+			ports = append(ports, port)
+		}
+	}
+	for i, port := range ports {
+		if newPort, ok := s.portOverrides[port]; ok {
+			ports[i] = newPort
+		}
+	}
+	return ports
+}
+
+// portEnv returns the environment that moves the service to its reassigned ports.
+// Callers must hold the lock.
+func (s *Service) portEnv() process.ServiceEnv {
+	if len(s.portOverrides) == 0 {
+		return nil
+	}
+	env := make(process.ServiceEnv)
+	if s.Config.Type == "npm" || s.Config.Type == "compose" || s.Config.Type == "command" {
+		// Dev servers only listen on a single port, commands can use ${PORT} and
+		// compose files ${PORT} or ${PORT_<NAME>}
+		if ports := s.knownPorts(); len(ports) > 0 {
+			env["PORT"] = strconv.Itoa(ports[0])
+			if s.Config.Type == "compose" {
+				env[composePortVar(s.Config.ComposeService)] = env["PORT"]
+			}
+		}
+		return env
+	}
+
+	var urls []string
+	for _, u := range s.knownURLs() {
+		if port, ok := portcheck.PortFromURL(u); ok {
+			if newPort, ok := s.portOverrides[port]; ok {
+				u = portcheck.ReplacePort(u, newPort)
+			}
+		}
+		urls = append(urls, u)
+	}
+	if len(urls) == 0 {
+		for _, port := range s.knownPorts() {
+			urls = append(urls, fmt.Sprintf("http://localhost:%d", port))
+		}
+	}
+	env["ASPNETCORE_URLS"] = strings.Join(urls, ";")
+	return env
+}
+
+// composePortVar returns the variable moving a single compose service, "api" -> "PORT_API"
+func composePortVar(service string) string {
+	return "PORT_" + envName(service)
+}
+
+// canReassign reports whether a reassigned port reaches the process. Compose
+// services only pick it up when the compose file publishes ${PORT} or
+// ${PORT_<NAME>}. Callers must hold the lock.
+func (s *Service) canReassign() bool {
+	if s.Config.Type != "compose" {
+		return true
+	}
+	path, err := s.Config.ComposeFilePath()
+	if err != nil {
+		return false
+	}
+	project, err := compose.Load(path)
+	if err != nil {
+		return false
+	}
+	svc := project.Service(s.Config.ComposeService)
+	return svc != nil && (slices.Contains(svc.PortVars, "PORT") || slices.Contains(svc.PortVars, composePortVar(s.Config.ComposeService)))
+}
+
+// findPortConflicts checks the known ports of the service against existing listeners
+func (s *Service) findPortConflicts() ([]portcheck.Conflict, error) {
+	s.mu.RLock()
+	ports := s.knownPorts()
+	path := s.Config.Path
+	s.mu.RUnlock()
+	if len(ports) == 0 {
+		return nil, nil
+	}
+	return portcheck.FindConflicts(ports, path)
+}
+
+// checkPorts reports taken ports before spawning. On conflict the frontend is
+// asked to pick a resolution and ErrPortConflict is returned.
+func (s *Service) checkPorts(withoutBuild bool) error {
+	if compose, ok := s.processManager.(*process.ComposeService); ok && compose.IsUp() {
+		return nil // The container holds its own ports, compose up leaves it running
+	}
+	conflicts, err := s.findPortConflicts()
+	if err != nil {
+		s.logMessage(process.Warn, fmt.Sprintf("Port check failed: %v", err))
+		return nil
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	for _, c := range conflicts {
+		s.logMessage(process.Err, fmt.Sprintf("Port %d is already in use by %s", c.Port, c.Describe()))
+	}
+	s.mu.RLock()
+	canReassign := s.canReassign()
+	s.mu.RUnlock()
+	s.app.EmitToFrontend("portConflict", s.ID, map[string]interface{}{
+		"conflicts":    conflicts,
+		"withoutBuild": withoutBuild,
+		"canReassign":  canReassign,
+	})
+	return ErrPortConflict
+}
+
+// ResolvePortConflict applies the chosen resolution for a port conflict and starts the service
+func (s *Service) ResolvePortConflict(action string, withoutBuild bool) error {
+	conflicts, err := s.findPortConflicts()
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case PortConflictKill:
+		for _, c := range conflicts {
+			s.logMessage(process.Inf, fmt.Sprintf("Killing %s holding port %d", c.Describe(), c.Port))
+			if err := portcheck.KillHolder(c); err != nil {
+				s.logMessage(process.Err, fmt.Sprintf("Failed to free port %d: %v", c.Port, err))
+				return err
+			}
+		}
+	case PortConflictReassign:
+		var messages []string
+		s.mu.Lock()
+		if !s.canReassign() {
+			s.mu.Unlock()
+			return fmt.Errorf("the compose file does not publish ${PORT} or ${%s}, another port would not reach the container", composePortVar(s.Config.ComposeService))
+		}
+		if s.portOverrides == nil {
+			s.portOverrides = make(map[int]int)
+		}
+		for _, c := range conflicts {
+			newPort, err := portcheck.FreePort()
+			if err != nil {
+				s.mu.Unlock()
+				return err
+			}
+			// Key by the configured port so repeated conflicts replace the earlier choice
+			original := c.Port
+			for old, reassigned := range s.portOverrides {
+				if reassigned == c.Port {
+					original = old
+				}
+			}
+			s.portOverrides[original] = newPort
+			messages = append(messages, fmt.Sprintf("Port %d reassigned to %d", c.Port, newPort))
+		}
+		env := s.mergedEnv()
+		s.processManager.UpdateConfig(s.Config.Path, env, s.args)
+		s.mu.Unlock()
+		for _, message := range messages {
+			s.logMessage(process.Inf, message)
+		}
+	case PortConflictAbort:
+		s.logMessage(process.Warn, "Start aborted because of a port conflict")
+		return nil
+	default:
+		return fmt.Errorf("unknown port conflict action: %s", action)
+	}
+
+	if withoutBuild {
+		return s.StartWithoutBuild()
+	}
+	return s.Start()
+}
@@ -3,35 +3,69 @@ package service
 import (
 	"crypto/rand"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
+	"time"
 
 	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/portalloc"
 	"wails-launcher/pkg/process"
+	"wails-launcher/pkg/redact"
+	"wails-launcher/pkg/secrets"
 )
 
 // ServiceInfo represents service information
 type ServiceInfo struct {
-	Name         string                `json:"name"`
-	Path         string                `json:"path"`
-	Status       process.ServiceStatus `json:"status"`
-	URL          *string               `json:"url,omitempty"`
-	Logs         []process.LogEntry    `json:"logs"`
-	Env          config.ServiceEnv     `json:"env"`
-	InheritedEnv config.ServiceEnv     `json:"inheritedEnv"`
-	Type         string                `json:"type"`
+	Name           string                 `json:"name"`
+	Path           string                 `json:"path"`
+	Status         process.ServiceStatus  `json:"status"`
+	URL            *string                `json:"url,omitempty"`
+	Logs           []process.LogEntry     `json:"logs"`
+	Env            config.ServiceEnv      `json:"env"`
+	InheritedEnv   config.ServiceEnv      `json:"inheritedEnv"`
+	Type           string                 `json:"type"`
+	Ports          []int                  `json:"ports,omitempty"`
+	NamedPorts     []config.PortSpec      `json:"namedPorts,omitempty"`
+	AllocatedPorts map[string]int         `json:"allocatedPorts,omitempty"`
+	Proxy          *config.ProxyRoute     `json:"proxy,omitempty"`
+	ProxyURL       string                 `json:"proxyUrl,omitempty"`
+	DependsOn      []string               `json:"dependsOn,omitempty"`
+	EnvFiles       []string               `json:"envFiles,omitempty"`
+	LaunchProfile  string                 `json:"launchProfile,omitempty"`
+	Tags           []string               `json:"tags,omitempty"`
+	PackageManager string                 `json:"packageManager,omitempty"`
+	Script         string                 `json:"script,omitempty"`
+	Args           []string               `json:"args,omitempty"`
+	ComposeFile    string                 `json:"composeFile,omitempty"`
+	ComposeService string                 `json:"composeService,omitempty"`
+	Command        string                 `json:"command,omitempty"`
+	Problems       config.ValidationError `json:"problems,omitempty"` // Why the config is invalid, set by the app
 }
 
 // Service represents a service
 type Service struct {
 	ID             string
+	groupId        string // Where references to other services are looked up first
 	Config         config.ServiceConfig
 	InheritedEnv   config.ServiceEnv
 	Status         process.ServiceStatus
 	Logs           []process.LogEntry
 	URL            *string
+	lastURL        string             // Last detected URL, kept across restarts for port checks
+	portOverrides  map[int]int        // Ports reassigned after a conflict, old -> new
+	allocatedPorts map[string]int     // Named ports allocated for the current run
+	envProblems    []envProblem       // Unreadable files and unresolved references found while merging the environment
+	env            process.ServiceEnv // Environment from the last merge
+	args           []string           // Run arguments from the last merge, selecting the launch profile
+	secretValues   []string           // Secrets referenced by the environment
+	sensitive      []string           // Secrets and values of sensitive env vars, masked in logs
 	processManager process.ServiceManager
 	mu             sync.RWMutex
 	app            AppInterface
+	ports          *portalloc.Allocator
+	secrets        *secrets.Store
+	redaction      *redact.Policy
 }
 
 // AppInterface defines the interface that Service needs from the App
@@ -40,33 +74,34 @@ type AppInterface interface {
 }
 
 // NewService creates a new service
-func NewService(id string, config config.ServiceConfig, inheritedEnv config.ServiceEnv, app AppInterface) *Service {
+func NewService(id string, groupId string, config config.ServiceConfig, inheritedEnv config.ServiceEnv, app AppInterface, ports *portalloc.Allocator, secretStore *secrets.Store, redaction *redact.Policy) *Service {
 	service := &Service{
 		ID:           id,
+		groupId:      groupId,
 		Config:       config,
 		InheritedEnv: inheritedEnv,
 		Status:       process.Stopped,
 		Logs:         []process.LogEntry{},
 		app:          app,
+		ports:        ports,
+		secrets:      secretStore,
+		redaction:    redaction,
 	}
+	ports.Register(id, groupId, config.Name, portNames(config.NamedPorts))
 
-	mergedEnv := make(process.ServiceEnv)
-	for k, v := range inheritedEnv {
-		mergedEnv[k] = v
-	}
-	for k, v := range config.Env {
-		if v == "" {
-			delete(mergedEnv, k)
-		} else {
-			mergedEnv[k] = v
-		}
-	}
-
-	if config.Type == "npm" {
-		service.processManager = process.NewNpmService(config.Path, mergedEnv)
-	} else {
+	mergedEnv := service.mergedEnv()
+	switch config.Type {
+	case "npm":
+		manager, script, startScript := npmScripts(config)
+		service.processManager = process.NewNpmService(config.Path, manager, script, startScript, mergedEnv, service.args)
+	case "compose":
+		file, _ := config.ComposeFilePath() // Validation reports a missing file
+		service.processManager = process.NewComposeService(config.Path, file, config.ComposeService, mergedEnv, service.args)
+	case "command":
+		service.processManager = process.NewCommandService(config.Path, config.Command, mergedEnv, service.args)
+	default:
 		// Default to dotnet for backward compatibility
-		service.processManager = process.NewDotnetService(config.Path, mergedEnv)
+		service.processManager = process.NewDotnetService(config.Path, mergedEnv, service.args)
 	}
 
 	go service.listenEvents()
@@ -79,23 +114,12 @@ func (s *Service) listenEvents() {
 	for {
 		select {
 		case log := <-logChan:
-			s.mu.Lock()
-			s.Logs = append(s.Logs, log)
-			if len(s.Logs) > 100 { // MAX_LOGS
-				s.Logs = s.Logs[1:]
-			}
-			s.mu.Unlock()
-			// Emit to frontend
-			s.app.EmitToFrontend("newLog", s.ID, map[string]interface{}{"log": log})
-			if log.Level == process.Err {
-				s.app.EmitToFrontend("statusUpdate", s.ID, map[string]interface{}{
-					"status": s.Status,
-					"url":    s.URL,
-				})
-			}
+			s.appendLog(log)
 		case url := <-urlChan:
 			s.mu.Lock()
 			s.URL = &url
+			s.lastURL = url
+			s.ports.SetURL(s.ID, url)
 			s.mu.Unlock()
 			s.app.EmitToFrontend("statusUpdate", s.ID, map[string]interface{}{
 				"status": s.Status,
@@ -120,25 +144,167 @@ func (s *Service) listenEvents() {
 	}
 }
 
-// UpdateConfig updates the service configuration
-func (s *Service) UpdateConfig(config config.ServiceConfig, inheritedEnv config.ServiceEnv) {
+// appendLog redacts a log entry, then stores it and forwards it to the frontend
+func (s *Service) appendLog(log process.LogEntry) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.Config = config
-	s.InheritedEnv = inheritedEnv
+	log.Message = s.redaction.Redact(log.Message, s.sensitive)
+	log.Raw = s.redaction.Redact(log.Raw, s.sensitive)
+	s.Logs = append(s.Logs, log)
+	if len(s.Logs) > 100 { // MAX_LOGS
+		s.Logs = s.Logs[1:]
+	}
+	s.mu.Unlock()
+	// Emit to frontend
+	s.app.EmitToFrontend("newLog", s.ID, map[string]interface{}{"log": log})
+	if log.Level == process.Err {
+		s.app.EmitToFrontend("statusUpdate", s.ID, map[string]interface{}{
+			"status": s.Status,
+			"url":    s.URL,
+		})
+	}
+}
+
+// logMessage adds a launcher generated message to the service log
+func (s *Service) logMessage(level process.LogLevel, message string) {
+	s.appendLog(process.LogEntry{
+		Timestamp: time.Now().Format(time.RFC3339),
+		Level:     level,
+		Message:   message,
+		Raw:       message,
+		Stream:    "launcher",
+	})
+}
 
+// mergedEnv merges the environment layers, later ones win: the inherited
+// group environment, the launch profile of dotnet services, the service's env
+// files and the service env, where an empty value removes the variable.
+// Allocated and reassigned ports are applied last, then ${...} references are
+// expanded. The run arguments are updated along.
+// Callers must hold the lock.
+func (s *Service) mergedEnv() process.ServiceEnv {
 	mergedEnv := make(process.ServiceEnv)
-	for k, v := range inheritedEnv {
+	for k, v := range s.InheritedEnv {
+		mergedEnv[k] = v
+	}
+	var problems []envProblem
+	profile, err := launchProfile(s.Config)
+	if err != nil {
+		problems = append(problems, envProblem{process.Warn, fmt.Sprintf("Cannot apply launch profile: %v", err)})
+	}
+	if profile != nil {
+		for k, v := range profile.Profile.Env() {
+			mergedEnv[k] = v
+		}
+	}
+	fileEnv, missing, err := config.LoadEnvFiles(s.Config.EnvFilePaths())
+	if err != nil {
+		problems = append(problems, envProblem{process.Warn, fmt.Sprintf("Cannot read env file: %v", err)})
+	}
+	for _, path := range missing {
+		problems = append(problems, envProblem{process.Warn, fmt.Sprintf("Env file %s does not exist", path)})
+	}
+	for k, v := range fileEnv {
 		mergedEnv[k] = v
 	}
-	for k, v := range config.Env {
+	for k, v := range s.Config.Env {
 		if v == "" {
 			delete(mergedEnv, k)
 		} else {
 			mergedEnv[k] = v
 		}
 	}
-	s.processManager.UpdateConfig(config.Path, mergedEnv)
+	for k, v := range s.allocatedEnv() {
+		mergedEnv[k] = v
+	}
+	for k, v := range s.portEnv() {
+		mergedEnv[k] = v
+	}
+	// Let other services refer to this one before its URL is detected
+	if urls := s.knownURLs(); len(urls) > 0 {
+		s.ports.SetURL(s.ID, urls[0])
+	}
+	s.secretValues = nil
+	problems = append(problems, s.expandEnv(mergedEnv)...)
+	args, argProblems := launchArgs(profile, mergedEnv)
+	s.envProblems = append(problems, argProblems...)
+	s.args = withExtraArgs(s.Config, args)
+	s.env = mergedEnv
+	s.updateSensitive()
+	return mergedEnv
+}
+
+// updateSensitive collects the values masked in logs. Callers must hold the lock.
+func (s *Service) updateSensitive() {
+	s.sensitive = append(slices.Clone(s.secretValues), s.redaction.SensitiveValues(s.env)...)
+}
+
+// RefreshRedaction picks up changed redaction rules
+func (s *Service) RefreshRedaction() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateSensitive()
+}
+
+// UpdateConfig updates the service configuration. It reports whether the
+// path, the effective environment, the run arguments or what runs changed,
+// which a running process only picks up when restarted.
+func (s *Service) UpdateConfig(config config.ServiceConfig, inheritedEnv config.ServiceEnv) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.Config
+	oldEnv, oldArgs := s.env, s.args
+	s.Config = config
+	s.InheritedEnv = inheritedEnv
+	s.ports.Register(s.ID, s.groupId, config.Name, portNames(config.NamedPorts))
+	env := s.mergedEnv()
+	s.processManager.UpdateConfig(config.Path, env, s.args)
+	switch manager := s.processManager.(type) {
+	case *process.NpmService:
+		manager.SetScripts(npmScripts(config))
+	case *process.ComposeService:
+		file, _ := config.ComposeFilePath()
+		manager.SetService(file, config.ComposeService)
+	case *process.CommandService:
+		manager.SetCommand(config.Command)
+	}
+	return config.Path != old.Path || !maps.Equal(env, oldEnv) || !slices.Equal(s.args, oldArgs) ||
+		config.PackageManager != old.PackageManager || config.Script != old.Script ||
+		config.ComposeFile != old.ComposeFile || config.ComposeService != old.ComposeService ||
+		config.Command != old.Command
+}
+
+// Env returns the environment the process is started with, as of the last
+// merge, with secret values masked
+func (s *Service) Env() process.ServiceEnv {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	env := maps.Clone(s.env)
+	for key, value := range env {
+		env[key] = redact.MaskValues(value, s.secretValues)
+	}
+	return env
+}
+
+// SensitiveValues returns the values masked in the service's logs
+func (s *Service) SensitiveValues() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.sensitive)
+}
+
+// IsActive reports whether the service process is running or on its way up
+func (s *Service) IsActive() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Status == process.Starting || s.Status == process.Initializing || s.Status == process.Running
+}
+
+// Restart stops the service and starts it again with its current configuration
+func (s *Service) Restart() error {
+	if err := s.Stop(); err != nil {
+		return err
+	}
+	return s.Start()
 }
 
 // GetInfo returns service information
@@ -146,27 +312,66 @@ func (s *Service) GetInfo() ServiceInfo {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return ServiceInfo{
-		Name:         s.Config.Name,
-		Path:         s.Config.Path,
-		Status:       s.Status,
-		URL:          s.URL,
-		Logs:         s.Logs,
-		Env:          s.Config.Env,
-		InheritedEnv: s.InheritedEnv,
-		Type:         s.Config.Type,
+		Name:           s.Config.Name,
+		Path:           s.Config.Path,
+		Status:         s.Status,
+		URL:            s.URL,
+		Logs:           s.Logs,
+		Env:            s.Config.Env,
+		InheritedEnv:   s.InheritedEnv,
+		Type:           s.Config.Type,
+		Ports:          s.Config.Ports,
+		NamedPorts:     s.Config.NamedPorts,
+		AllocatedPorts: s.allocatedPorts,
+		Proxy:          s.Config.Proxy,
+		DependsOn:      s.Config.DependsOn,
+		EnvFiles:       s.Config.EnvFiles,
+		LaunchProfile:  s.Config.LaunchProfile,
+		Tags:           s.Config.Tags,
+		PackageManager: s.Config.PackageManager,
+		Script:         s.Config.Script,
+		Args:           s.Config.Args,
+		ComposeFile:    s.Config.ComposeFile,
+		ComposeService: s.Config.ComposeService,
+		Command:        s.Config.Command,
+	}
+}
+
+// Endpoint returns the detected URL and current status of the service
+func (s *Service) Endpoint() (string, process.ServiceStatus) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.URL == nil {
+		return "", s.Status
 	}
+	return *s.URL, s.Status
 }
 
 // Start starts the service
 func (s *Service) Start() error {
+	if err := s.prepareStart(false); err != nil {
+		return err
+	}
 	return s.processManager.Start()
 }
 
 // StartWithoutBuild starts the service without building
 func (s *Service) StartWithoutBuild() error {
+	if err := s.prepareStart(true); err != nil {
+		return err
+	}
 	return s.processManager.StartWithoutBuild()
 }
 
+// prepareStart allocates named ports, refreshes the process environment
+// and checks the service's ports for conflicts
+func (s *Service) prepareStart(withoutBuild bool) error {
+	if err := s.allocatePorts(); err != nil {
+		return err
+	}
+	return s.checkPorts(withoutBuild)
+}
+
 // Stop stops the service
 func (s *Service) Stop() error {
 	return s.processManager.Stop()
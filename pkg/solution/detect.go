@@ -0,0 +1,124 @@
+package solution
+
+import (
+	"encoding/xml"
+	"os"
+	"strings"
+)
+
+// Kinds of projects reported by Detect
+const (
+	KindWeb     = "web"
+	KindWorker  = "worker"
+	KindAspire  = "aspire"
+	KindConsole = "console"
+	KindTool    = "tool"
+	KindTest    = "test"
+	KindLibrary = "library"
+	KindUnknown = "unknown"
+)
+
+// Detection tells whether a project is worth launching and why
+type Detection struct {
+	Kind     string
+	Runnable bool // Selected for import by default
+	Reason   string
+}
+
+// testPackages are package references that make a project a test project
+var testPackages = []string{"microsoft.net.test.sdk", "xunit", "nunit", "mstest.testframework", "mstest"}
+
+// projectFile holds the parts of an SDK-style project file Detect looks at
+type projectFile struct {
+	Sdk  string `xml:"Sdk,attr"`
+	Sdks []struct {
+		Name string `xml:"Name,attr"`
+	} `xml:"Sdk"`
+	PropertyGroups []struct {
+		OutputType    string `xml:"OutputType"`
+		IsAspireHost  string `xml:"IsAspireHost"`
+		IsTestProject string `xml:"IsTestProject"`
+		PackAsTool    string `xml:"PackAsTool"`
+	} `xml:"PropertyGroup"`
+	ItemGroups []struct {
+		PackageReferences []struct {
+			Include string `xml:"Include,attr"`
+		} `xml:"PackageReference"`
+	} `xml:"ItemGroup"`
+}
+
+// Detect classifies a project from its project file: web, worker and Aspire
+// AppHost projects are runnable, console apps and dotnet tools are listed but
+// not selected, test projects and libraries are skipped.
+func Detect(path string) Detection {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Detection{Kind: KindUnknown, Reason: "cannot read the project file: " + err.Error()}
+	}
+	var project projectFile
+	if err := xml.Unmarshal(data, &project); err != nil {
+		return Detection{Kind: KindUnknown, Reason: "cannot parse the project file: " + err.Error()}
+	}
+
+	sdks := strings.Split(project.Sdk, ";")
+	for _, sdk := range project.Sdks {
+		sdks = append(sdks, sdk.Name)
+	}
+	hasSdk := func(name string) bool {
+		for _, sdk := range sdks {
+			// Versions are written as Name/1.0.0
+			sdk, _, _ = strings.Cut(strings.TrimSpace(sdk), "/")
+			if strings.EqualFold(sdk, name) {
+				return true
+			}
+		}
+		return false
+	}
+	property := func(get func(i int) string) string {
+		value := ""
+		for i := range project.PropertyGroups {
+			if v := strings.TrimSpace(get(i)); v != "" {
+				value = v // Later groups win, like in MSBuild
+			}
+		}
+		return value
+	}
+	outputType := property(func(i int) string { return project.PropertyGroups[i].OutputType })
+	isAspireHost := property(func(i int) string { return project.PropertyGroups[i].IsAspireHost })
+	isTestProject := property(func(i int) string { return project.PropertyGroups[i].IsTestProject })
+	packAsTool := property(func(i int) string { return project.PropertyGroups[i].PackAsTool })
+
+	var testPackage string
+	for _, group := range project.ItemGroups {
+		for _, ref := range group.PackageReferences {
+			for _, name := range testPackages {
+				if strings.EqualFold(ref.Include, name) {
+					testPackage = ref.Include
+				}
+			}
+		}
+	}
+
+	switch {
+	case strings.EqualFold(isTestProject, "true"):
+		return Detection{Kind: KindTest, Reason: "IsTestProject is set"}
+	case testPackage != "":
+		return Detection{Kind: KindTest, Reason: "references " + testPackage}
+	case strings.EqualFold(isAspireHost, "true") || hasSdk("Aspire.AppHost.Sdk"):
+		return Detection{Kind: KindAspire, Runnable: true, Reason: "Aspire AppHost"}
+	case hasSdk("Microsoft.NET.Sdk.Web"):
+		return Detection{Kind: KindWeb, Runnable: true, Reason: "uses Microsoft.NET.Sdk.Web"}
+	case hasSdk("Microsoft.NET.Sdk.BlazorWebAssembly"):
+		return Detection{Kind: KindWeb, Runnable: true, Reason: "Blazor WebAssembly app"}
+	case hasSdk("Microsoft.NET.Sdk.Worker"):
+		return Detection{Kind: KindWorker, Runnable: true, Reason: "uses Microsoft.NET.Sdk.Worker"}
+	case strings.EqualFold(packAsTool, "true"):
+		return Detection{Kind: KindTool, Reason: "packed as a dotnet tool"}
+	case strings.EqualFold(outputType, "Exe") || strings.EqualFold(outputType, "WinExe"):
+		return Detection{Kind: KindConsole, Reason: "console app (OutputType " + outputType + ")"}
+	case project.Sdk == "" && len(project.Sdks) == 0:
+		return Detection{Kind: KindUnknown, Reason: "not an SDK-style project"}
+	default:
+		return Detection{Kind: KindLibrary, Reason: "class library"}
+	}
+}
@@ -0,0 +1,232 @@
+package solution
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ProjectExtensions are the project files imported from solutions
+var ProjectExtensions = []string{".csproj", ".fsproj", ".vbproj"}
+
+// Extensions are the solution formats Load understands
+var Extensions = []string{".sln", ".slnx", ".slnf"}
+
+// Project is a project referenced by a solution
+type Project struct {
+	Name   string // Project name, the file name without extension unless the solution names it
+	Path   string // Absolute path of the project file
+	Folder string // Solution folder path, e.g. "src/Services", empty at the root
+}
+
+// Solution is the list of projects of a solution file
+type Solution struct {
+	Name     string // File name without extension
+	Projects []Project
+}
+
+// Load reads a .sln, .slnx or .slnf file. Only projects with one of the
+// ProjectExtensions are returned.
+func Load(path string) (*Solution, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var projects []Project
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".sln":
+		projects, err = parseSLN(data, filepath.Dir(path))
+	case ".slnx":
+		projects, err = parseSLNX(data, filepath.Dir(path))
+	case ".slnf":
+		projects, err = loadFilter(data, filepath.Dir(path))
+	default:
+		return nil, fmt.Errorf("unsupported solution file %s, expected one of %s", path, strings.Join(Extensions, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	return &Solution{
+		Name:     strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Projects: projects,
+	}, nil
+}
+
+// IsProjectFile reports whether path has one of the ProjectExtensions
+func IsProjectFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, projectExt := range ProjectExtensions {
+		if ext == projectExt {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve turns a project path from a solution into an absolute one,
+// solutions written on Windows use backslashes
+func resolve(dir, path string) string {
+	path = filepath.FromSlash(strings.ReplaceAll(path, "\\", "/"))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// solutionFolderType is the project type GUID of solution folders in .sln files
+const solutionFolderType = "2150E333-8FDC-42A3-9474-1A3956D46DE8"
+
+var (
+	slnProjectRegex = regexp.MustCompile(`^Project\("\{([^}]+)\}"\)\s*=\s*"([^"]+)",\s*"([^"]+)",\s*"\{([^}]+)\}"`)
+	slnNestedRegex  = regexp.MustCompile(`^\{([^}]+)\}\s*=\s*\{([^}]+)\}$`)
+)
+
+// parseSLN parses a classic .sln file, solution folders come from the
+// NestedProjects section
+func parseSLN(data []byte, dir string) ([]Project, error) {
+	type entry struct {
+		name, path string
+		folder     bool
+	}
+	entries := make(map[string]entry)
+	var order []string
+	parents := make(map[string]string)
+	inNested := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "GlobalSection(NestedProjects)"):
+			inNested = true
+		case inNested && line == "EndGlobalSection":
+			inNested = false
+		case inNested:
+			if m := slnNestedRegex.FindStringSubmatch(line); m != nil {
+				parents[strings.ToUpper(m[1])] = strings.ToUpper(m[2])
+			}
+		default:
+			if m := slnProjectRegex.FindStringSubmatch(line); m != nil {
+				id := strings.ToUpper(m[4])
+				entries[id] = entry{name: m[2], path: m[3], folder: strings.EqualFold(m[1], solutionFolderType)}
+				order = append(order, id)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// folderPath walks up the nesting, guarding against cycles in broken files
+	folderPath := func(id string) string {
+		var names []string
+		seen := make(map[string]bool)
+		for parent, ok := parents[id]; ok && !seen[parent]; parent, ok = parents[parent] {
+			seen[parent] = true
+			if folder, exists := entries[parent]; exists {
+				names = append([]string{folder.name}, names...)
+			}
+		}
+		return strings.Join(names, "/")
+	}
+
+	var projects []Project
+	for _, id := range order {
+		e := entries[id]
+		if e.folder || !IsProjectFile(e.path) {
+			continue
+		}
+		projects = append(projects, Project{Name: e.name, Path: resolve(dir, e.path), Folder: folderPath(id)})
+	}
+	return projects, nil
+}
+
+// slnxProject is a project element of a .slnx file
+type slnxProject struct {
+	Path string `xml:"Path,attr"`
+	Name string `xml:"DisplayName,attr"`
+}
+
+// slnxFolder is a folder element, its name is the full path like "/src/Services/"
+type slnxFolder struct {
+	Name     string        `xml:"Name,attr"`
+	Projects []slnxProject `xml:"Project"`
+}
+
+// parseSLNX parses the XML solution format
+func parseSLNX(data []byte, dir string) ([]Project, error) {
+	var doc struct {
+		XMLName  xml.Name      `xml:"Solution"`
+		Projects []slnxProject `xml:"Project"`
+		Folders  []slnxFolder  `xml:"Folder"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var projects []Project
+	add := func(folder string, p slnxProject) {
+		if !IsProjectFile(p.Path) {
+			return
+		}
+		path := resolve(dir, p.Path)
+		name := p.Name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		projects = append(projects, Project{Name: name, Path: path, Folder: folder})
+	}
+	for _, p := range doc.Projects {
+		add("", p)
+	}
+	for _, folder := range doc.Folders {
+		for _, p := range folder.Projects {
+			add(strings.Trim(folder.Name, "/"), p)
+		}
+	}
+	return projects, nil
+}
+
+// loadFilter reads a .slnf solution filter: the solution it refers to,
+// restricted to the listed projects
+func loadFilter(data []byte, dir string) ([]Project, error) {
+	var filter struct {
+		Solution struct {
+			Path     string   `json:"path"`
+			Projects []string `json:"projects"`
+		} `json:"solution"`
+	}
+	// Visual Studio saves filters with a UTF-8 byte order mark
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	if err := json.Unmarshal(data, &filter); err != nil {
+		return nil, err
+	}
+	if filter.Solution.Path == "" {
+		return nil, fmt.Errorf("the filter does not name a solution")
+	}
+	solutionPath := resolve(dir, filter.Solution.Path)
+	if strings.EqualFold(filepath.Ext(solutionPath), ".slnf") {
+		return nil, fmt.Errorf("a solution filter cannot refer to another filter")
+	}
+	full, err := Load(solutionPath)
+	if err != nil {
+		return nil, err
+	}
+	// Project paths in the filter are relative to the solution
+	included := make(map[string]bool)
+	for _, path := range filter.Solution.Projects {
+		included[resolve(filepath.Dir(solutionPath), path)] = true
+	}
+	var projects []Project
+	for _, p := range full.Projects {
+		if included[p.Path] {
+			projects = append(projects, p)
+		}
+	}
+	return projects, nil
+}
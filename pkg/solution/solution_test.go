@@ -0,0 +1,215 @@
+package solution
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testSLN has a solution folder nested in another, a project at the root and
+// a non-.NET project that is skipped
+const testSLN = `
+Microsoft Visual Studio Solution File, Format Version 12.00
+# Visual Studio Version 17
+Project("{2150E333-8FDC-42A3-9474-1A3956D46DE8}") = "src", "src", "{11111111-1111-1111-1111-111111111111}"
+EndProject
+Project("{2150E333-8FDC-42A3-9474-1A3956D46DE8}") = "Services", "Services", "{22222222-2222-2222-2222-222222222222}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Api", "src\Services\Api\Api.csproj", "{33333333-3333-3333-3333-333333333333}"
+EndProject
+Project("{F2A71F9B-5D33-465A-A702-920D77279786}") = "Worker", "src\Worker\Worker.fsproj", "{44444444-4444-4444-4444-444444444444}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Tools", "Tools.csproj", "{55555555-5555-5555-5555-555555555555}"
+EndProject
+Project("{54A90642-561A-4BB1-A94E-469ADEE60C69}") = "web", "web\web.esproj", "{66666666-6666-6666-6666-666666666666}"
+EndProject
+Global
+	GlobalSection(NestedProjects) = preSolution
+		{22222222-2222-2222-2222-222222222222} = {11111111-1111-1111-1111-111111111111}
+		{33333333-3333-3333-3333-333333333333} = {22222222-2222-2222-2222-222222222222}
+		{44444444-4444-4444-4444-444444444444} = {11111111-1111-1111-1111-111111111111}
+	EndGlobalSection
+EndGlobal
+`
+
+func TestParseSLN(t *testing.T) {
+	dir := filepath.FromSlash("/repo")
+	tests := []struct {
+		name string
+		data string
+		want []Project
+	}{
+		{
+			name: "folders and project types",
+			data: testSLN,
+			want: []Project{
+				{Name: "Api", Path: filepath.Join(dir, "src", "Services", "Api", "Api.csproj"), Folder: "src/Services"},
+				{Name: "Worker", Path: filepath.Join(dir, "src", "Worker", "Worker.fsproj"), Folder: "src"},
+				{Name: "Tools", Path: filepath.Join(dir, "Tools.csproj")},
+			},
+		},
+		{
+			name: "lower case GUIDs and CRLF",
+			data: "Project(\"{fae04ec0-301f-11d3-bf4b-00c04f79efbc}\") = \"Api\", \"Api\\Api.csproj\", \"{aaaaaaaa-0000-0000-0000-000000000000}\"\r\n" +
+				"Project(\"{2150e333-8fdc-42a3-9474-1a3956d46de8}\") = \"apps\", \"apps\", \"{bbbbbbbb-0000-0000-0000-000000000000}\"\r\n" +
+				"\tGlobalSection(NestedProjects) = preSolution\r\n" +
+				"\t\t{AAAAAAAA-0000-0000-0000-000000000000} = {bbbbbbbb-0000-0000-0000-000000000000}\r\n" +
+				"\tEndGlobalSection\r\n",
+			want: []Project{{Name: "Api", Path: filepath.Join(dir, "Api", "Api.csproj"), Folder: "apps"}},
+		},
+		{
+			name: "nesting cycle",
+			data: "Project(\"{2150E333-8FDC-42A3-9474-1A3956D46DE8}\") = \"a\", \"a\", \"{A}\"\n" +
+				"Project(\"{2150E333-8FDC-42A3-9474-1A3956D46DE8}\") = \"b\", \"b\", \"{B}\"\n" +
+				"Project(\"{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}\") = \"Api\", \"Api.csproj\", \"{C}\"\n" +
+				"GlobalSection(NestedProjects) = preSolution\n{C} = {A}\n{A} = {B}\n{B} = {A}\nEndGlobalSection\n",
+			want: []Project{{Name: "Api", Path: filepath.Join(dir, "Api.csproj"), Folder: "b/a"}},
+		},
+		{name: "no projects", data: "Microsoft Visual Studio Solution File, Format Version 12.00\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projects, err := parseSLN([]byte(tt.data), dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(projects, tt.want) {
+				t.Errorf("got %+v, want %+v", projects, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSLNX(t *testing.T) {
+	dir := filepath.FromSlash("/repo")
+	tests := []struct {
+		name      string
+		data      string
+		want      []Project
+		expectErr bool
+	}{
+		{
+			name: "folders and display names",
+			data: `<Solution>
+  <Folder Name="/src/Services/">
+    <Project Path="src/Services/Api/Api.csproj" />
+    <Project Path="src\Services\Jobs\Jobs.vbproj" DisplayName="Background Jobs" />
+  </Folder>
+  <Project Path="Tools/Tools.csproj" />
+  <Project Path="web/web.esproj" />
+</Solution>`,
+			want: []Project{
+				{Name: "Tools", Path: filepath.Join(dir, "Tools", "Tools.csproj")},
+				{Name: "Api", Path: filepath.Join(dir, "src", "Services", "Api", "Api.csproj"), Folder: "src/Services"},
+				{Name: "Background Jobs", Path: filepath.Join(dir, "src", "Services", "Jobs", "Jobs.vbproj"), Folder: "src/Services"},
+			},
+		},
+		{name: "empty solution", data: "<Solution />"},
+		{
+			name: "byte order mark",
+			data: "\xEF\xBB\xBF<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<Solution><Project Path=\"Api/Api.csproj\" /></Solution>",
+			want: []Project{{Name: "Api", Path: filepath.Join(dir, "Api", "Api.csproj")}},
+		},
+		{name: "not a solution", data: "<Project />", expectErr: true},
+		{name: "malformed", data: "<Solution>", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projects, err := parseSLNX([]byte(tt.data), dir)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", projects)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(projects, tt.want) {
+				t.Errorf("got %+v, want %+v", projects, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFilter(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("App.sln", testSLN)
+
+	tests := []struct {
+		name      string
+		path      string // Of the filter, relative to the solution
+		filter    string
+		want      []string // Project names
+		expectErr bool
+	}{
+		{
+			name:   "selected projects",
+			path:   "Api.slnf",
+			filter: `{"solution": {"path": "App.sln", "projects": ["src\\Services\\Api\\Api.csproj", "Tools.csproj", "Missing.csproj"]}}`,
+			want:   []string{"Api", "Tools"},
+		},
+		{
+			name:   "byte order mark",
+			path:   "Bom.slnf",
+			filter: "\xEF\xBB\xBF" + `{"solution": {"path": "App.sln", "projects": ["Tools.csproj"]}}`,
+			want:   []string{"Tools"},
+		},
+		{
+			name:   "solution in a parent directory",
+			path:   "filters/Worker.slnf",
+			filter: `{"solution": {"path": "../App.sln", "projects": ["src/Worker/Worker.fsproj"]}}`,
+			want:   []string{"Worker"},
+		},
+		{name: "no solution", path: "None.slnf", filter: `{"solution": {"projects": []}}`, expectErr: true},
+		{name: "filter of a filter", path: "Nested.slnf", filter: `{"solution": {"path": "Api.slnf"}}`, expectErr: true},
+		{name: "missing solution", path: "Missing.slnf", filter: `{"solution": {"path": "Missing.sln"}}`, expectErr: true},
+		{name: "invalid JSON", path: "Invalid.slnf", filter: `{"solution": `, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sln, err := Load(write(tt.path, tt.filter))
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", sln)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, p := range sln.Projects {
+				names = append(names, p.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("got %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadUnsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "App.csproj")
+	if err := os.WriteFile(path, []byte("<Project />"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for a project file")
+	}
+}
@@ -0,0 +1,184 @@
+package traffic
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// HAR 1.2 document, see http://www.softwareishard.com/blog/har-12-spec/
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HAR renders entries as a HAR 1.2 JSON document
+func HAR(entries []Entry) ([]byte, error) {
+	doc := harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "wails-launcher", Version: "1.0"},
+		Entries: make([]harEntry, 0, len(entries)),
+	}}
+
+	for _, e := range entries {
+		reqHeaders := http.Header(e.RequestHeaders)
+		respHeaders := http.Header(e.ResponseHeaders)
+
+		request := harRequest{
+			Method:      e.Method,
+			URL:         e.URL,
+			HTTPVersion: e.Proto,
+			Cookies:     harCookies((&http.Request{Header: reqHeaders}).Cookies()),
+			Headers:     harHeaders(reqHeaders),
+			QueryString: harQuery(e.URL),
+			HeadersSize: -1,
+			BodySize:    e.RequestSize,
+		}
+		if e.RequestSize > 0 {
+			request.PostData = &harPostData{
+				MimeType: reqHeaders.Get("Content-Type"),
+				Text:     e.RequestBody,
+			}
+		}
+
+		content := harContent{
+			Size:     e.ResponseSize,
+			MimeType: respHeaders.Get("Content-Type"),
+			Text:     e.ResponseBody,
+		}
+		if e.ResponseTruncated {
+			content.Comment = "truncated"
+		}
+
+		var comment string
+		if e.Upgraded {
+			comment = "connection upgraded"
+		}
+
+		doc.Log.Entries = append(doc.Log.Entries, harEntry{
+			StartedDateTime: e.StartedAt.Format(time.RFC3339Nano),
+			Time:            e.DurationMs,
+			Request:         request,
+			Response: harResponse{
+				Status:      e.Status,
+				StatusText:  http.StatusText(e.Status),
+				HTTPVersion: e.Proto,
+				Cookies:     harCookies((&http.Response{Header: respHeaders}).Cookies()),
+				Headers:     harHeaders(respHeaders),
+				Content:     content,
+				RedirectURL: respHeaders.Get("Location"),
+				HeadersSize: -1,
+				BodySize:    e.ResponseSize,
+			},
+			Timings: harTimings{Wait: e.DurationMs},
+			Comment: comment,
+		})
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// harHeaders flattens headers into sorted name/value pairs
+func harHeaders(header http.Header) []harNameValue {
+	pairs := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// harQuery lists the query parameters of a URL
+func harQuery(rawURL string) []harNameValue {
+	pairs := []harNameValue{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return pairs
+	}
+	for name, values := range u.Query() {
+		for _, value := range values {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// harCookies converts parsed cookies to name/value pairs
+func harCookies(cookies []*http.Cookie) []harNameValue {
+	pairs := []harNameValue{}
+	for _, c := range cookies {
+		pairs = append(pairs, harNameValue{Name: c.Name, Value: c.Value})
+	}
+	return pairs
+}
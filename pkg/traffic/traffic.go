@@ -0,0 +1,225 @@
+package traffic
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limits of the in-memory store
+const (
+	DefaultMaxEntries = 500       // Exchanges kept per service
+	DefaultMaxBody    = 64 * 1024 // Bytes of each body kept
+)
+
+// Entry is a recorded HTTP exchange
+type Entry struct {
+	ID                string              `json:"id"`
+	ServiceID         string              `json:"serviceId"`
+	StartedAt         time.Time           `json:"startedAt"`
+	DurationMs        float64             `json:"durationMs"`
+	Method            string              `json:"method"`
+	URL               string              `json:"url"`
+	Path              string              `json:"path"`
+	Proto             string              `json:"proto"`
+	Status            int                 `json:"status"`
+	RequestHeaders    map[string][]string `json:"requestHeaders"`
+	ResponseHeaders   map[string][]string `json:"responseHeaders"`
+	RequestBody       string              `json:"requestBody,omitempty"`
+	ResponseBody      string              `json:"responseBody,omitempty"`
+	RequestSize       int64               `json:"requestSize"`
+	ResponseSize      int64               `json:"responseSize"`
+	RequestTruncated  bool                `json:"requestTruncated,omitempty"`
+	ResponseTruncated bool                `json:"responseTruncated,omitempty"`
+	Upgraded          bool                `json:"upgraded,omitempty"` // Switched to WebSocket, bodies are not captured
+}
+
+// Store keeps the most recent exchanges of each service in memory
+type Store struct {
+	mu         sync.RWMutex
+	entries    map[string][]Entry
+	maxEntries int
+	maxBody    int
+	nextID     atomic.Uint64
+}
+
+// NewStore creates a store with the default limits
+func NewStore() *Store {
+	return &Store{
+		entries:    make(map[string][]Entry),
+		maxEntries: DefaultMaxEntries,
+		maxBody:    DefaultMaxBody,
+	}
+}
+
+// Add stores an entry, dropping the oldest one of the service when full
+func (s *Store) Add(entry Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := append(s.entries[entry.ServiceID], entry)
+	if len(list) > s.maxEntries {
+		list = list[len(list)-s.maxEntries:]
+	}
+	s.entries[entry.ServiceID] = list
+}
+
+// Entries returns the recorded exchanges of a service, oldest first
+func (s *Store) Entries(serviceId string) []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Entry{}, s.entries[serviceId]...)
+}
+
+// Clear removes the recorded exchanges of a service
+func (s *Store) Clear(serviceId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, serviceId)
+}
+
+// Capture serves the request through next while recording the exchange for serviceId
+func (s *Store) Capture(serviceId string, w http.ResponseWriter, r *http.Request, next http.Handler) {
+	started := time.Now()
+
+	reqBody := &cappedBuffer{limit: s.maxBody}
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = &teeReadCloser{Reader: io.TeeReader(r.Body, reqBody), Closer: r.Body}
+	}
+	recorder := &responseRecorder{ResponseWriter: w, body: cappedBuffer{limit: s.maxBody}}
+	// An upgraded connection stays open until the socket closes, so it is
+	// recorded as soon as the proxy takes it over
+	var once sync.Once
+	record := func() { once.Do(func() { s.record(serviceId, started, r, reqBody, recorder) }) }
+	recorder.onHijack = record
+
+	next.ServeHTTP(recorder, r)
+	record()
+}
+
+// record stores the exchange seen by recorder
+func (s *Store) record(serviceId string, started time.Time, r *http.Request, reqBody *cappedBuffer, recorder *responseRecorder) {
+	status := recorder.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	s.Add(Entry{
+		ID:                formatID(s.nextID.Add(1)),
+		ServiceID:         serviceId,
+		StartedAt:         started,
+		DurationMs:        float64(time.Since(started).Microseconds()) / 1000,
+		Method:            r.Method,
+		URL:               scheme + "://" + r.Host + r.URL.RequestURI(),
+		Path:              r.URL.Path,
+		Proto:             r.Proto,
+		Status:            status,
+		RequestHeaders:    r.Header.Clone(),
+		ResponseHeaders:   recorder.Header().Clone(),
+		RequestBody:       reqBody.String(),
+		ResponseBody:      recorder.body.String(),
+		RequestSize:       reqBody.total,
+		ResponseSize:      recorder.body.total,
+		RequestTruncated:  reqBody.truncated(),
+		ResponseTruncated: recorder.body.truncated(),
+		Upgraded:          recorder.hijacked,
+	})
+}
+
+// formatID renders a sequence number as a short ID
+func formatID(n uint64) string {
+	const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+	if n == 0 {
+		return "0"
+	}
+	var buf []byte
+	for n > 0 {
+		buf = append([]byte{digits[n%36]}, buf...)
+		n /= 36
+	}
+	return string(buf)
+}
+
+// cappedBuffer keeps the first limit bytes written and counts the rest
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+	total int64
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.total += int64(len(p))
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
+
+func (b *cappedBuffer) truncated() bool {
+	return b.total > int64(b.buf.Len())
+}
+
+// teeReadCloser closes the original body while reading through a tee
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// responseRecorder captures the status and body while passing them through
+type responseRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     cappedBuffer
+	hijacked bool
+	onHijack func()
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// Hijack hands the connection to the proxy for a protocol switch. The proxy
+// writes the 101 response to the connection itself, so it is recorded here.
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	r.status = http.StatusSwitchingProtocols
+	r.hijacked = true
+	if r.onHijack != nil {
+		r.onHijack()
+	}
+	return conn, brw, nil
+}
+
+// Unwrap exposes the underlying writer so flushing and WebSocket hijacking keep working
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
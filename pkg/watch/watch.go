@@ -0,0 +1,175 @@
+package watch
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDelay is how long the files must be quiet before a change is reported
+const DefaultDelay = 300 * time.Millisecond
+
+// Watcher reports changes to a set of files. Their directories are watched
+// rather than the files themselves, so editors that save by replacing the file
+// and files that do not exist yet are handled too. Bursts of changes are
+// debounced into a single call of the change handler.
+type Watcher struct {
+	fs       *fsnotify.Watcher
+	onChange func()
+	delay    time.Duration
+	mu       sync.Mutex
+	files    map[string]bool
+	dirs     map[string]bool
+	written  map[string][sha256.Size]byte // Content last written by the app itself
+	changed  map[string]bool              // Files changed since the last report
+	timer    *time.Timer
+}
+
+// New starts a watcher calling onChange after the watched files changed
+func New(onChange func()) (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		fs:       fs,
+		onChange: onChange,
+		delay:    DefaultDelay,
+		files:    make(map[string]bool),
+		dirs:     make(map[string]bool),
+		written:  make(map[string][sha256.Size]byte),
+		changed:  make(map[string]bool),
+	}
+	go w.run()
+	return w, nil
+}
+
+// SetFiles replaces the set of watched files
+func (w *Watcher) SetFiles(paths []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		files[path] = true
+		dirs[filepath.Dir(path)] = true
+	}
+
+	for dir := range w.dirs {
+		if !dirs[dir] {
+			w.fs.Remove(dir)
+		}
+	}
+	var firstErr error
+	for dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.fs.Add(dir); err != nil {
+			delete(dirs, dir)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	w.files = files
+	w.dirs = dirs
+	return firstErr
+}
+
+// Written records that the app itself just wrote path. Changes leaving the
+// file with that content are not reported.
+func (w *Watcher) Written(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	data, err := os.ReadFile(path)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		delete(w.written, path)
+		return
+	}
+	w.written[path] = sha256.Sum256(data)
+}
+
+// ownWrite reports whether path still has the content the app wrote, the
+// caller holds w.mu. Once it differs the file is treated as edited elsewhere.
+func (w *Watcher) ownWrite(path string) bool {
+	sum, ok := w.written[path]
+	if !ok {
+		return false
+	}
+	if data, err := os.ReadFile(path); err == nil && sha256.Sum256(data) == sum {
+		return true
+	}
+	delete(w.written, path)
+	return false
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+	return w.fs.Close()
+}
+
+// run receives file system events until the watcher is closed
+func (w *Watcher) run() {
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			w.mu.Lock()
+			if path := filepath.Clean(event.Name); w.files[path] {
+				w.changed[path] = true
+				w.schedule()
+			}
+			w.mu.Unlock()
+		case _, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// schedule (re)starts the debounce timer, the caller holds w.mu
+func (w *Watcher) schedule() {
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.delay, w.report)
+}
+
+// report calls the change handler unless all changed files were written by the app
+func (w *Watcher) report() {
+	w.mu.Lock()
+	edited := false
+	for path := range w.changed {
+		if !w.ownWrite(path) {
+			edited = true
+		}
+	}
+	w.changed = make(map[string]bool)
+	w.mu.Unlock()
+	if edited {
+		w.onChange()
+	}
+}
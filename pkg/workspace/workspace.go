@@ -0,0 +1,303 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DevScripts are the package.json scripts that start a package for
+// development, in order of preference
+var DevScripts = []string{"dev", "start:dev", "serve", "develop", "start"}
+
+// lockFiles tell which package manager installed a directory, a pnpm
+// workspace without a lock file yet is still a pnpm one
+var lockFiles = []struct{ file, manager string }{
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "yarn"},
+	{"bun.lock", "bun"},
+	{"bun.lockb", "bun"},
+	{"package-lock.json", "npm"},
+	{"pnpm-workspace.yaml", "pnpm"},
+}
+
+// Package is a package of a workspace
+type Package struct {
+	Name    string            // Name from package.json, the directory name without one
+	Dir     string            // Absolute directory of the package
+	Folder  string            // Parent directory relative to the workspace root, e.g. "apps"
+	Scripts map[string]string // Scripts of package.json
+	Script  string            // Dev script picked from DevScripts, empty for libraries
+	Reason  string            // Why the package is considered an app or not
+}
+
+// App reports whether the package has a script to run
+func (p Package) App() bool {
+	return p.Script != ""
+}
+
+// Workspace is a JS monorepo
+type Workspace struct {
+	Root           string
+	Name           string // Name of the root package.json, the directory name without one
+	PackageManager string // npm, pnpm, yarn or bun
+	Tool           string // "nx" or "turbo" when the repo uses one, empty otherwise
+	Packages       []Package
+}
+
+// packageJSON holds the parts of package.json the launcher looks at
+type packageJSON struct {
+	Name           string            `json:"name"`
+	Scripts        map[string]string `json:"scripts"`
+	PackageManager string            `json:"packageManager"` // Corepack field, e.g. "pnpm@9.1.0"
+	Workspaces     json.RawMessage   `json:"workspaces"`     // A list of patterns or {"packages": [...]}
+}
+
+// readPackageJSON reads the package.json of a directory
+func readPackageJSON(dir string) (*packageJSON, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	var pkg packageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", filepath.Join(dir, "package.json"), err)
+	}
+	return &pkg, nil
+}
+
+// ReadPackage reads the package in a directory and picks its dev script
+func ReadPackage(dir string) (Package, error) {
+	pkg, err := readPackageJSON(dir)
+	if err != nil {
+		return Package{}, err
+	}
+	p := Package{Name: pkg.Name, Dir: dir, Scripts: pkg.Scripts}
+	if p.Name == "" {
+		p.Name = filepath.Base(dir)
+	}
+	for _, script := range DevScripts {
+		if _, ok := pkg.Scripts[script]; ok {
+			p.Script = script
+			p.Reason = "runs the " + script + " script"
+			break
+		}
+	}
+	if p.Script == "" {
+		p.Reason = "no " + strings.Join(DevScripts[:len(DevScripts)-1], ", ") + " or " + DevScripts[len(DevScripts)-1] + " script"
+	}
+	return p, nil
+}
+
+// DetectPackageManager returns the package manager of a package directory:
+// the packageManager field of package.json or the lock file found in the
+// directory or the closest parent, npm when there is neither
+func DetectPackageManager(dir string) string {
+	for {
+		if pkg, err := readPackageJSON(dir); err == nil && pkg.PackageManager != "" {
+			manager, _, _ := strings.Cut(pkg.PackageManager, "@")
+			return manager
+		}
+		for _, lock := range lockFiles {
+			if _, err := os.Stat(filepath.Join(dir, lock.file)); err == nil {
+				return lock.manager
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "npm"
+		}
+		dir = parent
+	}
+}
+
+// Load reads the workspace at path, the root directory or one of its
+// package.json, pnpm-workspace.yaml, nx.json or turbo.json files. Packages
+// come from pnpm-workspace.yaml or the workspaces of package.json; Nx repos
+// without either are looked up in apps, libs and packages. Only packages with
+// their own package.json are returned.
+func Load(path string) (*Workspace, error) {
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		root = filepath.Dir(root)
+	}
+
+	ws := &Workspace{Root: root, Name: filepath.Base(root), PackageManager: DetectPackageManager(root)}
+	switch {
+	case exists(filepath.Join(root, "nx.json")):
+		ws.Tool = "nx"
+	case exists(filepath.Join(root, "turbo.json")):
+		ws.Tool = "turbo"
+	}
+
+	pkg, err := readPackageJSON(root)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if pkg != nil && pkg.Name != "" {
+		ws.Name = pkg.Name
+	}
+
+	patterns, err := pnpmPatterns(root)
+	if err != nil {
+		return nil, err
+	}
+	if patterns == nil && pkg != nil {
+		if patterns, err = workspacePatterns(pkg.Workspaces); err != nil {
+			return nil, fmt.Errorf("invalid workspaces in %s: %w", filepath.Join(root, "package.json"), err)
+		}
+	}
+	if patterns == nil && ws.Tool == "nx" {
+		patterns = []string{"apps/*", "libs/*", "packages/*"}
+	}
+	if patterns == nil {
+		return nil, fmt.Errorf("no workspaces found in %s, expected pnpm-workspace.yaml or workspaces in package.json", root)
+	}
+
+	dirs, err := expand(root, patterns)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		p, err := ReadPackage(dir)
+		if err != nil {
+			return nil, err
+		}
+		if rel, err := filepath.Rel(root, filepath.Dir(dir)); err == nil && rel != "." {
+			p.Folder = filepath.ToSlash(rel)
+		}
+		ws.Packages = append(ws.Packages, p)
+	}
+	return ws, nil
+}
+
+// pnpmPatterns reads the package patterns of pnpm-workspace.yaml, nil
+// without the file
+func pnpmPatterns(root string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "pnpm-workspace.yaml"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Packages []string `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid pnpm-workspace.yaml: %w", err)
+	}
+	if doc.Packages == nil {
+		doc.Packages = []string{} // pnpm treats a file without packages as a workspace of the root only
+	}
+	return doc.Packages, nil
+}
+
+// workspacePatterns reads the workspaces field of package.json, which npm
+// and yarn write as a list and yarn classic also as {"packages": [...]}
+func workspacePatterns(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var patterns []string
+	if err := json.Unmarshal(raw, &patterns); err == nil {
+		return patterns, nil
+	}
+	var object struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, err
+	}
+	return object.Packages, nil
+}
+
+// expand returns the package directories matching the patterns, sorted.
+// Patterns starting with ! exclude directories, ** matches any depth.
+func expand(root string, patterns []string) ([]string, error) {
+	var include, exclude []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			exclude = append(exclude, strings.TrimPrefix(negated, "./"))
+		} else if pattern != "" {
+			include = append(include, pattern)
+		}
+	}
+
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == "node_modules" || (path != root && strings.HasPrefix(d.Name(), ".")) {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if matchAny(include, rel) && !matchAny(exclude, rel) && exists(filepath.Join(path, "package.json")) {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// matchAny reports whether a slash separated path matches one of the patterns
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if match(strings.Split(pattern, "/"), strings.Split(path, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// match matches path segments against pattern segments, ** matching any
+// number of segments
+func match(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if match(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+	return match(pattern[1:], path[1:])
+}
+
+// exists reports whether a file exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
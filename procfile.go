@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/dotenv"
+	"wails-launcher/pkg/process"
+	"wails-launcher/pkg/procfile"
+	"wails-launcher/pkg/workspace"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ImportProcfile creates a group from the processes of a Procfile and returns
+// the problems of those that cannot start
+func (a *App) ImportProcfile(path string) ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	groupId, err := a.groups.ImportProcfile(path)
+	if err != nil {
+		return nil, err
+	}
+	return a.addImportedGroup(groupId), nil
+}
+
+// ExportProcfile writes the services of a group as a Procfile, so foreman,
+// overmind and the like run them the same way. Commands of services outside
+// the Procfile's directory change into theirs first. Variables all services
+// share go to a .env next to the Procfile, unless one not written by the
+// launcher is there, the others are exported in front of the command. Sensitive variables and values with ${...} references need the
+// launcher and are left out, the user is told which. Known secrets are masked.
+// An empty path asks where to save it. Returns the path written, empty when
+// cancelled.
+func (a *App) ExportProcfile(groupId string, path string) (string, error) {
+	a.mu.RLock()
+	group, ok := a.groups.GetGroups()[groupId]
+	a.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("group %s not found", groupId)
+	}
+	if len(group.Services) == 0 {
+		return "", fmt.Errorf("group %s has no services", group.Name)
+	}
+	if path == "" {
+		if a.ctx == nil {
+			return "", fmt.Errorf("app context not initialized")
+		}
+		var err error
+		path, err = runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:            "Export Procfile",
+			DefaultDirectory: commonDir(group.Services),
+			DefaultFilename:  procfile.FileName,
+		})
+		if err != nil || path == "" {
+			return "", err
+		}
+	}
+
+	envs := make(map[string]map[string]string)
+	omitted := make(map[string]bool)
+	for serviceId, svc := range group.Services {
+		env, err := a.exportEnv(groupId, svc, omitted)
+		if err != nil {
+			return "", fmt.Errorf("cannot export the environment of %s: %w", svc.Name, err)
+		}
+		envs[serviceId] = env
+	}
+	envPath := filepath.Join(filepath.Dir(path), procfile.EnvFileName)
+	shared := sharedEnv(envs, envPath)
+
+	sensitive := a.sensitiveValues()
+	if len(shared) > 0 {
+		data := a.redaction.Redact(envHeader+string(dotenv.Format(shared)), sensitive)
+		if err := config.WriteFileAtomic(envPath, []byte(data), 0644); err != nil {
+			return "", err
+		}
+	}
+	entries := procfileEntries(group.Services, envs, filepath.Dir(path))
+	data := a.redaction.Redact(string(procfile.Format(entries)), sensitive)
+	if err := config.WriteFileAtomic(path, []byte(data), 0644); err != nil {
+		return "", err
+	}
+
+	if len(omitted) > 0 && a.ctx != nil {
+		names := slices.Sorted(maps.Keys(omitted))
+		runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
+			Type:    runtime.WarningDialog,
+			Title:   "Procfile exported",
+			Message: "These variables were left out as they are sensitive or use ${...} references: " + strings.Join(names, ", "),
+		})
+	}
+	return path, nil
+}
+
+// exportEnv returns the variables a service is configured with, except those
+// that cannot be exported, whose names are added to omitted. Launch profile
+// variables are left to dotnet run.
+func (a *App) exportEnv(groupId string, svc config.ServiceConfig, omitted map[string]bool) (map[string]string, error) {
+	variables, err := a.PreviewEnv(groupId, svc)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string)
+	for _, v := range variables {
+		switch {
+		case strings.HasPrefix(v.Source, "launch profile "):
+		case a.redaction.Sensitive(v.Key) || strings.Contains(v.Value, "${"):
+			omitted[v.Key] = true
+		default:
+			env[v.Key] = v.Value
+		}
+	}
+	return env, nil
+}
+
+// envHeader starts the .env files written by ExportProcfile, other .env files
+// are never overwritten
+const envHeader = "# Variables shared by the processes of the Procfile, written by the launcher\n"
+
+// sharedEnv moves the variables set to the same value in every service out of
+// envs and returns them. Nothing is shared when envPath exists and was not
+// written by the launcher, every service then keeps its variables.
+func sharedEnv(envs map[string]map[string]string, envPath string) map[string]string {
+	if data, err := os.ReadFile(envPath); !os.IsNotExist(err) && !strings.HasPrefix(string(data), envHeader) {
+		return nil
+	}
+	var shared map[string]string
+	for _, env := range envs {
+		if shared == nil {
+			shared = maps.Clone(env)
+			continue
+		}
+		maps.DeleteFunc(shared, func(key, value string) bool {
+			other, ok := env[key]
+			return !ok || other != value
+		})
+	}
+	for _, env := range envs {
+		for key := range shared {
+			delete(env, key)
+		}
+	}
+	return shared
+}
+
+// procfileEntries turns services into Procfile entries run from dir with
+// their variables in envs, ordered by port so importing the file again hands
+// out the same ports, then by name
+func procfileEntries(services map[string]config.ServiceConfig, envs map[string]map[string]string, dir string) []procfile.Entry {
+	ids := slices.Collect(maps.Keys(services))
+	sort.Slice(ids, func(i, j int) bool {
+		si, sj := services[ids[i]], services[ids[j]]
+		pi, pj := firstPort(si), firstPort(sj)
+		if pi != pj {
+			return pi < pj
+		}
+		return si.Name < sj.Name
+	})
+
+	entries := make([]procfile.Entry, 0, len(ids))
+	taken := make(map[string]bool)
+	for _, id := range ids {
+		svc := services[id]
+		name := procfile.Name(svc.Name)
+		for i := 2; taken[name]; i++ {
+			name = procfile.Name(svc.Name) + "-" + strconv.Itoa(i)
+		}
+		taken[name] = true
+		command := serviceCommand(svc)
+		if env := envs[id]; len(env) > 0 {
+			// Exported so commands chained with && see them too
+			assignments := make([]string, 0, len(env))
+			for _, key := range slices.Sorted(maps.Keys(env)) {
+				assignments = append(assignments, key+"="+process.ShellQuote(env[key]))
+			}
+			command = "export " + strings.Join(assignments, " ") + " && " + command
+		}
+		if rel, err := filepath.Rel(dir, svc.Path); err != nil {
+			command = "cd " + process.ShellQuote(svc.Path) + " && " + command
+		} else if rel != "." {
+			command = "cd " + process.ShellQuote(filepath.ToSlash(rel)) + " && " + command
+		}
+		entries = append(entries, procfile.Entry{Name: name, Command: command})
+	}
+	return entries
+}
+
+// firstPort returns the first declared port of a service, services without
+// one sort last
+func firstPort(svc config.ServiceConfig) int {
+	if len(svc.Ports) > 0 {
+		return svc.Ports[0]
+	}
+	return 1 << 16
+}
+
+// serviceCommand returns the shell command running a service the way the
+// launcher does
+func serviceCommand(svc config.ServiceConfig) string {
+	var argv []string
+	switch svc.Type {
+	case "command":
+		return strings.Join(append([]string{svc.Command}, quoteAll(svc.Args)...), " ")
+	case "npm":
+		manager := svc.PackageManager
+		if manager == "" {
+			manager = workspace.DetectPackageManager(svc.Path)
+		}
+		script := svc.Script
+		if script == "" {
+			script = "dev"
+			if pkg, err := workspace.ReadPackage(svc.Path); err == nil && pkg.Script != "" {
+				script = pkg.Script
+			}
+		}
+		argv = []string{manager, "run", script}
+		if len(svc.Args) > 0 && manager == "npm" {
+			argv = append(argv, "--")
+		}
+		argv = append(argv, svc.Args...)
+	case "compose":
+		argv = []string{"docker", "compose"}
+		if svc.ComposeFile != "" {
+			argv = append(argv, "-f", svc.ComposeFile)
+		}
+		argv = append(append(append(argv, "up"), svc.Args...), svc.ComposeService)
+	default:
+		argv = []string{"dotnet", "run"}
+		if svc.LaunchProfile != "" {
+			argv = append(argv, "--launch-profile", svc.LaunchProfile)
+		}
+		if len(svc.Args) > 0 {
+			argv = append(append(argv, "--"), svc.Args...)
+		}
+	}
+	return strings.Join(quoteAll(argv), " ")
+}
+
+// commonDir returns the directory all services are in or below
+func commonDir(services map[string]config.ServiceConfig) string {
+	dir := ""
+	for _, svc := range services {
+		if dir == "" {
+			dir = svc.Path
+			continue
+		}
+		for dir != filepath.Dir(dir) {
+			if rel, err := filepath.Rel(dir, svc.Path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+	return dir
+}
+
+// quoteAll quotes each argument for sh
+func quoteAll(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = process.ShellQuote(arg)
+	}
+	return quoted
+}
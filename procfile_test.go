@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/procfile"
+)
+
+func TestSharedEnv(t *testing.T) {
+	envs := func() map[string]map[string]string {
+		return map[string]map[string]string{
+			"api": {"LOG": "debug", "REGION": "eu", "API_ONLY": "1"},
+			"web": {"LOG": "debug", "REGION": "us"},
+		}
+	}
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, procfile.EnvFileName)
+
+	got := envs()
+	if shared := sharedEnv(got, envPath); !reflect.DeepEqual(shared, map[string]string{"LOG": "debug"}) {
+		t.Errorf("got shared %v, want LOG only", shared)
+	}
+	want := map[string]map[string]string{"api": {"REGION": "eu", "API_ONLY": "1"}, "web": {"REGION": "us"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// A .env written by the launcher is replaced, any other is left alone
+	if err := os.WriteFile(envPath, []byte(envHeader+"LOG=info\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if shared := sharedEnv(envs(), envPath); len(shared) != 1 {
+		t.Errorf("got shared %v with the launcher's .env, want LOG", shared)
+	}
+	if err := os.WriteFile(envPath, []byte("LOG=info\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got = envs()
+	if shared := sharedEnv(got, envPath); shared != nil {
+		t.Errorf("got shared %v with the user's .env, want none", shared)
+	}
+	if !reflect.DeepEqual(got, envs()) {
+		t.Errorf("got %v, want the variables unchanged", got)
+	}
+}
+
+func TestProcfileEntries(t *testing.T) {
+	dir := filepath.FromSlash("/repo")
+	services := map[string]config.ServiceConfig{
+		"a": {Name: "web", Type: "command", Path: filepath.Join(dir, "web"), Command: "npm start", Ports: []int{3000}},
+		"b": {Name: "worker", Type: "command", Path: dir, Command: "bin/jobs"},
+	}
+	envs := map[string]map[string]string{"a": {"B": "two words", "A": "1"}}
+	want := []procfile.Entry{
+		{Name: "web", Command: "cd web && export A=1 B='two words' && npm start"},
+		{Name: "worker", Command: "bin/jobs"},
+	}
+	if got := procfileEntries(services, envs, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/project"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ProjectInfo describes a project whose .launcher.yaml is loaded
+type ProjectInfo struct {
+	Root       string `json:"root"`
+	File       string `json:"file"`
+	Discovered bool   `json:"discovered"` // Found from the working directory rather than added by the user
+	Error      string `json:"error,omitempty"`
+}
+
+// withProjects returns the local groups together with the groups of all known projects
+func (a *App) withProjects(cfg *config.Config) map[string]config.GroupConfig {
+	groups := make(map[string]config.GroupConfig)
+	for id, grp := range cfg.Groups {
+		groups[id] = grp
+	}
+
+	a.projectErrs = make(map[string]string)
+	for _, root := range a.projectRoots(cfg) {
+		projectGroups, err := project.Load(root)
+		if err != nil {
+			a.projectErrs[root] = err.Error()
+			continue
+		}
+		for id, grp := range projectGroups {
+			groups[id] = grp
+		}
+	}
+	return groups
+}
+
+// projectRoots returns the configured project roots plus the one containing the working directory
+func (a *App) projectRoots(cfg *config.Config) []string {
+	roots := append([]string{}, cfg.Projects...)
+	if wd, err := os.Getwd(); err == nil {
+		if root, found := project.Find(wd); found && !slices.Contains(roots, root) {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// saveProjects writes edits of project groups to the projects' local overrides files
+func (a *App) saveProjects(groups map[string]config.GroupConfig) {
+	byRoot := make(map[string]map[string]config.GroupConfig)
+	for id, grp := range groups {
+		if grp.Project == "" {
+			continue
+		}
+		if byRoot[grp.Project] == nil {
+			byRoot[grp.Project] = make(map[string]config.GroupConfig)
+		}
+		byRoot[grp.Project][id] = grp
+	}
+	for root, projectGroups := range byRoot {
+		if err := project.SaveLocal(root, projectGroups); err != nil {
+			a.projectErrs[root] = err.Error()
+		} else {
+			a.written(filepath.Join(root, project.LocalFileName))
+		}
+	}
+}
+
+// GetProjects returns the projects whose configuration is loaded
+func (a *App) GetProjects() []ProjectInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var projects []ProjectInfo
+	for _, root := range a.projectRoots(a.config) {
+		projects = append(projects, ProjectInfo{
+			Root:       root,
+			File:       filepath.Join(root, project.FileName),
+			Discovered: !slices.Contains(a.config.Projects, root),
+			Error:      a.projectErrs[root],
+		})
+	}
+	return projects
+}
+
+// AddProject loads the .launcher.yaml of a project directory or file. Without a
+// path a directory dialog is shown. Returns the project root, empty if cancelled.
+func (a *App) AddProject(path string) (string, error) {
+	if path == "" {
+		if a.ctx == nil {
+			return "", fmt.Errorf("app context not initialized")
+		}
+		var err error
+		path, err = runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: "Select Project Directory",
+		})
+		if err != nil || path == "" {
+			return "", err
+		}
+	}
+	root, err := project.Root(path)
+	if err != nil {
+		return "", err
+	}
+
+	a.mu.Lock()
+	if !slices.Contains(a.config.Projects, root) {
+		a.config.Projects = append(a.config.Projects, root)
+	}
+	a.mu.Unlock()
+	a.applyProjects()
+
+	if msg, failed := a.projectErrs[root]; failed {
+		return root, fmt.Errorf("%s", msg)
+	}
+	return root, nil
+}
+
+// RemoveProject stops loading a project's configuration and removes its services
+func (a *App) RemoveProject(root string) error {
+	a.mu.Lock()
+	index := slices.Index(a.config.Projects, root)
+	if index < 0 {
+		a.mu.Unlock()
+		return fmt.Errorf("project %s was not added", root)
+	}
+	a.config.Projects = slices.Delete(a.config.Projects, index, index+1)
+	a.mu.Unlock()
+	a.applyProjects()
+	return nil
+}
+
+// applyProjects saves the project list and reloads the groups and services
+func (a *App) applyProjects() {
+	a.mu.Lock()
+	a.config.Groups = localGroups(a.groups.GetGroups())
+	a.writeConfig()
+	a.mu.Unlock()
+	a.ReloadServices()
+}
+
+// localGroups returns the groups that are not defined by a project file
+func localGroups(groups map[string]config.GroupConfig) map[string]config.GroupConfig {
+	local := make(map[string]config.GroupConfig)
+	for id, grp := range groups {
+		if grp.Project == "" {
+			local[id] = grp
+		}
+	}
+	return local
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/devcert"
+	"wails-launcher/pkg/process"
+	"wails-launcher/pkg/proxy"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ProxyStatus describes the state of the built-in reverse proxy
+type ProxyStatus struct {
+	Address    string `json:"address"`
+	TLSAddress string `json:"tlsAddress,omitempty"`
+	Running    bool   `json:"running"`
+	Error      string `json:"error,omitempty"`
+}
+
+// GetProxyStatus returns the state of the built-in reverse proxy
+func (a *App) GetProxyStatus() ProxyStatus {
+	address := a.proxy.Address()
+	return ProxyStatus{
+		Address:    address,
+		TLSAddress: a.proxy.TLSAddress(),
+		Running:    address != "",
+		Error:      a.proxyError(),
+	}
+}
+
+// GetProxyConfig returns the proxy settings
+func (a *App) GetProxyConfig() config.ProxyConfig {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.proxyConfig()
+}
+
+// proxyConfig returns the proxy settings. Callers must hold the lock.
+func (a *App) proxyConfig() config.ProxyConfig {
+	if a.config.Proxy == nil {
+		return config.ProxyConfig{}
+	}
+	return *a.config.Proxy
+}
+
+// UpdateProxyConfig changes the proxy settings and restarts its listeners
+func (a *App) UpdateProxyConfig(proxyConfig config.ProxyConfig) error {
+	a.mu.Lock()
+	a.config.Proxy = &proxyConfig
+	a.saveConfig()
+	update := a.proxyUpdate()
+	a.mu.Unlock()
+	if err := a.applyProxy(update); err != "" {
+		return fmt.Errorf("%s", err)
+	}
+	return nil
+}
+
+// ExportCACertificate writes the development CA certificate so it can be
+// added to the system or browser trust store. Without a path a save dialog
+// is shown. Returns the path written, empty if the dialog was cancelled.
+func (a *App) ExportCACertificate(path string) (string, error) {
+	ca, err := a.certificateAuthority()
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		if a.ctx == nil {
+			return "", fmt.Errorf("app context not initialized")
+		}
+		path, err = runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "Export CA Certificate",
+			DefaultFilename: "wails-launcher-ca.pem",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "PEM Certificate", Pattern: "*.pem;*.crt"},
+			},
+		})
+		if err != nil || path == "" {
+			return "", err
+		}
+	}
+	if err := ca.Export(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// certificateAuthority loads the development CA, creating it on first use
+func (a *App) certificateAuthority() (*devcert.Authority, error) {
+	a.caMu.Lock()
+	defer a.caMu.Unlock()
+	if a.ca != nil {
+		return a.ca, nil
+	}
+	dir, err := devcert.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	ca, err := devcert.LoadOrCreate(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load development CA: %w", err)
+	}
+	a.ca = ca
+	return ca, nil
+}
+
+// proxyRoutes builds the proxy routing table from the services' proxy settings
+func (a *App) proxyRoutes() []proxy.Route {
+	var routes []proxy.Route
+	for serviceId, enriched := range a.groups.GetGroupServices() {
+		route := enriched.Config.Proxy
+		if route == nil || (route.Host == "" && route.PathPrefix == "") {
+			continue
+		}
+		routes = append(routes, proxy.Route{
+			ServiceID:   serviceId,
+			ServiceName: enriched.Config.Name,
+			Host:        route.Host,
+			PathPrefix:  route.PathPrefix,
+			StripPrefix: route.StripPrefix,
+			Record:      route.Record,
+		})
+	}
+	return routes
+}
+
+// proxyState is what the proxy is restarted with
+type proxyState struct {
+	gen    int
+	routes []proxy.Route
+	config config.ProxyConfig
+}
+
+// proxyUpdate captures the current routes and settings for applyProxy
+func (a *App) proxyUpdate() proxyState {
+	a.proxyMu.Lock()
+	a.proxyGen++
+	gen := a.proxyGen
+	a.proxyMu.Unlock()
+	return proxyState{gen: gen, routes: a.proxyRoutes(), config: a.proxyConfig()}
+}
+
+// refreshProxy applies the current routes, running the proxy only while any
+// service is mapped. It runs from saveConfig while a.mu is held, and stopping
+// the listeners waits for requests that resolve their target under a.mu, so
+// the proxy restarts in the background.
+func (a *App) refreshProxy() {
+	go a.applyProxy(a.proxyUpdate())
+}
+
+// applyProxy restarts the proxy listeners as needed and returns why they
+// could not start. A state older than the latest refresh is skipped.
+func (a *App) applyProxy(state proxyState) string {
+	a.proxyRun.Lock()
+	defer a.proxyRun.Unlock()
+	a.proxyMu.Lock()
+	stale := state.gen != a.proxyGen
+	a.proxyMu.Unlock()
+	if stale {
+		return a.proxyError()
+	}
+	err := a.restartProxy(state)
+	a.proxyMu.Lock()
+	defer a.proxyMu.Unlock()
+	a.proxyErr = ""
+	if err != nil {
+		a.proxyErr = err.Error()
+	}
+	return a.proxyErr
+}
+
+// restartProxy starts and stops the listeners for the routes and settings
+func (a *App) restartProxy(state proxyState) error {
+	a.proxy.SetRoutes(state.routes)
+	if len(state.routes) == 0 {
+		a.proxy.Stop()
+		return nil
+	}
+	if err := a.proxy.Start(state.config.Address); err != nil {
+		return err
+	}
+	if !state.config.HTTPS {
+		a.proxy.StopTLS()
+		return nil
+	}
+	ca, err := a.certificateAuthority()
+	if err != nil {
+		return err
+	}
+	var hosts []string
+	for _, route := range state.routes {
+		if route.Host != "" {
+			hosts = append(hosts, route.Host)
+		}
+	}
+	ca.SetHosts(hosts)
+	return a.proxy.StartTLS(state.config.HTTPSAddress, ca.TLSConfig())
+}
+
+// proxyError returns why the proxy could not start, empty when it runs
+func (a *App) proxyError() string {
+	a.proxyMu.Lock()
+	defer a.proxyMu.Unlock()
+	return a.proxyErr
+}
+
+// proxyTarget resolves where the proxy should forward requests for a service
+func (a *App) proxyTarget(serviceId string) proxy.Target {
+	a.mu.RLock()
+	srv, exists := a.services[serviceId]
+	a.mu.RUnlock()
+	if !exists {
+		return proxy.Target{Status: "removed"}
+	}
+	url, status := srv.Endpoint()
+	return proxy.Target{
+		URL:    url,
+		Ready:  status == process.Running,
+		Status: string(status),
+	}
+}
+
+// withProxyURL fills in the stable proxy URL of a service, preferring HTTPS when enabled
+func (a *App) withProxyURL(serviceId string, info ServiceInfo) ServiceInfo {
+	address, scheme := a.proxy.TLSAddress(), "https"
+	if address == "" {
+		address, scheme = a.proxy.Address(), "http"
+	}
+	if address == "" {
+		return info
+	}
+	for _, route := range a.proxy.Routes() {
+		if route.ServiceID == serviceId {
+			info.ProxyURL = proxy.PublicURL(route, address, scheme)
+			break
+		}
+	}
+	return info
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/redact"
+)
+
+// RedactionSettings are the redaction rules of the config and the built-in ones
+type RedactionSettings struct {
+	Config              config.RedactionConfig `json:"config"`
+	DefaultSensitiveEnv []string               `json:"defaultSensitiveEnv"`
+	DefaultPatterns     []string               `json:"defaultPatterns"`
+}
+
+// redactionRules returns the sensitive env var names and the patterns in effect
+func redactionRules(cfg *config.Config) ([]string, []string) {
+	var sensitiveEnv, patterns []string
+	if cfg.Redaction == nil || !cfg.Redaction.NoDefaults {
+		sensitiveEnv = append(sensitiveEnv, redact.DefaultSensitiveEnv...)
+		patterns = append(patterns, redact.DefaultPatterns...)
+	}
+	if cfg.Redaction != nil {
+		sensitiveEnv = append(sensitiveEnv, cfg.Redaction.SensitiveEnv...)
+		patterns = append(patterns, cfg.Redaction.Patterns...)
+	}
+	return sensitiveEnv, patterns
+}
+
+// GetRedaction returns what is masked in service logs and exports
+func (a *App) GetRedaction() RedactionSettings {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	settings := RedactionSettings{
+		DefaultSensitiveEnv: redact.DefaultSensitiveEnv,
+		DefaultPatterns:     redact.DefaultPatterns,
+	}
+	if a.config.Redaction != nil {
+		settings.Config = *a.config.Redaction
+	}
+	return settings
+}
+
+// UpdateRedaction replaces the redaction rules. They apply to log lines
+// captured from now on, stored lines stay as they are.
+func (a *App) UpdateRedaction(redaction config.RedactionConfig) error {
+	if errs := config.ValidateRedaction(&redaction); len(errs) > 0 {
+		return errs
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.config.Redaction = &redaction
+	a.redaction.SetRules(redactionRules(a.config))
+	a.saveConfig()
+	a.refreshRedaction()
+	return nil
+}
+
+// refreshRedaction makes the services pick up changed rules. The caller holds a.mu.
+func (a *App) refreshRedaction() {
+	for _, srv := range a.services {
+		srv.RefreshRedaction()
+	}
+}
+
+// sensitiveValues returns the values masked in the logs of any service, to be masked in exports too
+func (a *App) sensitiveValues() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var values []string
+	for _, srv := range a.services {
+		values = append(values, srv.SensitiveValues()...)
+	}
+	return values
+}
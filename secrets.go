@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/secrets"
+)
+
+// SecretsStatus tells where secrets are kept
+type SecretsStatus struct {
+	Backend string `json:"backend"`           // "keyring" or "file"
+	Warning string `json:"warning,omitempty"` // Why the keyring is not used
+}
+
+// openSecrets opens the secret store next to the config file
+func openSecrets() (*secrets.Store, error) {
+	path, err := config.Path()
+	if err != nil {
+		return nil, err
+	}
+	return secrets.Open(filepath.Dir(path))
+}
+
+// GetSecretsStatus returns where secrets are kept
+func (a *App) GetSecretsStatus() SecretsStatus {
+	status := SecretsStatus{}
+	if a.secrets != nil {
+		status.Backend = a.secrets.Backend()
+	}
+	if a.secretsErr != nil {
+		status.Warning = a.secretsErr.Error()
+	}
+	return status
+}
+
+// SetSecret stores a secret and returns the reference to put in an env value
+// instead. The value itself never reaches the config file.
+func (a *App) SetSecret(name string, value string) (string, error) {
+	if a.secrets == nil {
+		return "", fmt.Errorf("secrets are not available: %v", a.secretsErr)
+	}
+	if err := a.secrets.Set(name, value); err != nil {
+		return "", err
+	}
+	return secrets.Ref(name), nil
+}
+
+// DeleteSecret removes a stored secret
+func (a *App) DeleteSecret(name string) error {
+	if a.secrets == nil {
+		return fmt.Errorf("secrets are not available: %v", a.secretsErr)
+	}
+	return a.secrets.Delete(name)
+}
+
+// ListSecrets returns the names of the stored secrets
+func (a *App) ListSecrets() ([]string, error) {
+	if a.secrets == nil {
+		return []string{}, nil
+	}
+	return a.secrets.List()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"wails-launcher/pkg/traffic"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// GetTraffic returns the HTTP exchanges recorded by the proxy for a service, oldest first
+func (a *App) GetTraffic(serviceId string) []traffic.Entry {
+	return a.traffic.Entries(serviceId)
+}
+
+// ClearTraffic discards the recorded HTTP exchanges of a service
+func (a *App) ClearTraffic(serviceId string) {
+	a.traffic.Clear(serviceId)
+}
+
+// ExportTrafficHAR writes the recorded exchanges of a service as a HAR file,
+// redacted like the service logs. Without a path a save dialog is shown. Returns
+// the path written, empty if the dialog was cancelled.
+func (a *App) ExportTrafficHAR(serviceId string, path string) (string, error) {
+	data, err := traffic.HAR(a.traffic.Entries(serviceId))
+	if err != nil {
+		return "", err
+	}
+	data = a.redaction.RedactJSON(data, a.sensitiveValues())
+	if path == "" {
+		if a.ctx == nil {
+			return "", fmt.Errorf("app context not initialized")
+		}
+		path, err = runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "Export HTTP Traffic",
+			DefaultFilename: serviceId + ".har",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "HTTP Archive", Pattern: "*.har"},
+			},
+		})
+		if err != nil || path == "" {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+
+	"wails-launcher/pkg/config"
+	"wails-launcher/pkg/service"
+)
+
+// newServiceId stands in for the ID of a service that is not added yet
+const newServiceId = "new"
+
+// ValidateService checks a service config as if it were saved in the group,
+// serviceId is empty for a new service. Returns the problems found, if any.
+func (a *App) ValidateService(groupId string, serviceId string, cfg ServiceConfig) config.ValidationError {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if errs := a.validateCandidate(groupId, serviceId, cfg); errs != nil {
+		return errs
+	}
+	return config.ValidationError{}
+}
+
+// validateCandidate validates a service config together with the other services
+func (a *App) validateCandidate(groupId string, serviceId string, cfg ServiceConfig) config.ValidationError {
+	groups := a.groups.GetGroups()
+	grp, exists := groups[groupId]
+	if !exists {
+		return config.ValidationError{{Field: "group", Message: "group not found"}}
+	}
+	if serviceId == "" {
+		serviceId = newServiceId
+	}
+	grp.Services = maps.Clone(grp.Services)
+	grp.Services[serviceId] = cfg
+	groups[groupId] = grp
+	return config.Validate(groups).Services[serviceId]
+}
+
+// validateGroupChange validates a group's own fields before they are saved
+func validateGroupChange(grp config.GroupConfig) error {
+	if errs := config.ValidateGroup(grp); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validate records the problems of all services. Problems of a group and of
+// the global environment are reported for each service inheriting from them.
+func (a *App) validate() {
+	groups := a.groups.GetGroups()
+	validation := config.Validate(groups)
+	global := config.ValidateEnvironment(a.config.Environment)
+	problems := make(map[string]config.ValidationError)
+	for groupId, grp := range groups {
+		if name, _, ok := a.config.Environment.Profile(groupId); name != "" && !ok {
+			validation.Groups[groupId] = append(validation.Groups[groupId], config.FieldError{Field: "profile", Message: fmt.Sprintf("unknown profile %q", name)})
+		}
+		for serviceId := range grp.Services {
+			errs := append(config.ValidationError{}, global...)
+			for _, fe := range validation.Groups[groupId] {
+				errs = append(errs, config.FieldError{Field: "group." + fe.Field, Message: fe.Message})
+			}
+			errs = append(errs, validation.Services[serviceId]...)
+			if len(errs) > 0 {
+				problems[serviceId] = errs
+			}
+		}
+	}
+	a.problems = problems
+}
+
+// addImportedGroup saves a newly imported group and creates its services.
+// Invalid services are kept, like any invalid service they cannot start until
+// fixed, and their problems are returned. The caller holds a.mu.
+func (a *App) addImportedGroup(groupId string) []string {
+	a.validate()
+	a.saveConfig()
+
+	var problems []string
+	for serviceId, enriched := range a.groups.GetGroupServices() {
+		if enriched.GroupID != groupId {
+			continue
+		}
+		a.services[serviceId] = service.NewService(serviceId, groupId, enriched.Config, enriched.InheritedEnv, a, a.ports, a.secrets, a.redaction)
+		if errs, invalid := a.problems[serviceId]; invalid {
+			problems = append(problems, fmt.Sprintf("%s: %v", enriched.Config.Name, errs))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// checkStartable refuses to start a service whose config is invalid
+func (a *App) checkStartable(id string) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if errs, invalid := a.problems[id]; invalid {
+		return fmt.Errorf("service config is invalid: %w", errs)
+	}
+	return nil
+}
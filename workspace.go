@@ -0,0 +1,102 @@
+package main
+
+import (
+	"sort"
+
+	"wails-launcher/pkg/workspace"
+)
+
+// WorkspacePackage is a package of a JS workspace as offered for import
+type WorkspacePackage struct {
+	Name    string   `json:"name"`
+	Dir     string   `json:"dir"`
+	Folder  string   `json:"folder,omitempty"`
+	Script  string   `json:"script,omitempty"` // Dev script the service runs
+	Scripts []string `json:"scripts"`          // Scripts of package.json, sorted
+	App     bool     `json:"app"`              // Selected for import by default
+	Reason  string   `json:"reason"`
+}
+
+// WorkspacePreview is what importing a JS workspace would create
+type WorkspacePreview struct {
+	Root           string             `json:"root"`
+	Name           string             `json:"name"`
+	PackageManager string             `json:"packageManager"`
+	Tool           string             `json:"tool,omitempty"` // nx or turbo
+	Packages       []WorkspacePackage `json:"packages"`
+}
+
+// PreviewWorkspace lists the packages of a JS workspace before importing it
+func (a *App) PreviewWorkspace(path string) (WorkspacePreview, error) {
+	ws, err := workspace.Load(path)
+	if err != nil {
+		return WorkspacePreview{}, err
+	}
+	preview := WorkspacePreview{
+		Root:           ws.Root,
+		Name:           ws.Name,
+		PackageManager: ws.PackageManager,
+		Tool:           ws.Tool,
+		Packages:       make([]WorkspacePackage, 0, len(ws.Packages)),
+	}
+	for _, pkg := range ws.Packages {
+		preview.Packages = append(preview.Packages, WorkspacePackage{
+			Name:    pkg.Name,
+			Dir:     pkg.Dir,
+			Folder:  pkg.Folder,
+			Script:  pkg.Script,
+			Scripts: scriptNames(pkg.Scripts),
+			App:     pkg.App(),
+			Reason:  pkg.Reason,
+		})
+	}
+	return preview, nil
+}
+
+// ImportWorkspace creates a group from a JS workspace. scripts maps the
+// directories of the selected packages to the script each runs, empty for
+// its dev script; without a selection the packages with a dev script are
+// imported. The problems of packages that cannot start are returned.
+func (a *App) ImportWorkspace(path string, scripts map[string]string) ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	groupId, err := a.groups.ImportWorkspace(path, scripts)
+	if err != nil {
+		return nil, err
+	}
+	return a.addImportedGroup(groupId), nil
+}
+
+// PackageScripts is how an npm service in a directory can run
+type PackageScripts struct {
+	Scripts        map[string]string `json:"scripts"`             // Commands of package.json by script name
+	DevScript      string            `json:"devScript,omitempty"` // Run when the service picks no script
+	PackageManager string            `json:"packageManager"`      // Detected from the lock file
+}
+
+// GetPackageScripts reads the scripts of the package.json in a directory
+func (a *App) GetPackageScripts(path string) (PackageScripts, error) {
+	pkg, err := workspace.ReadPackage(path)
+	if err != nil {
+		return PackageScripts{}, err
+	}
+	scripts := PackageScripts{
+		Scripts:        pkg.Scripts,
+		DevScript:      pkg.Script,
+		PackageManager: workspace.DetectPackageManager(path),
+	}
+	if scripts.Scripts == nil {
+		scripts.Scripts = map[string]string{}
+	}
+	return scripts, nil
+}
+
+// scriptNames returns the names of package.json scripts, sorted
+func scriptNames(scripts map[string]string) []string {
+	names := make([]string, 0, len(scripts))
+	for name := range scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}